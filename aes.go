@@ -0,0 +1,205 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+)
+
+// Compression method of files encrypted with WinZip AES, the actual method is in the
+// extra field.
+const winzipAES = 99
+
+const (
+	winzipAESExtra  = 0x9901
+	winzipAESRounds = 1000
+	winzipAESTagLen = 10
+)
+
+var (
+	// Error returned when opening an encrypted file without a password, or with the
+	// wrong one.
+	ErrPassword = errors.New("zipfs: missing or wrong password")
+	// Error returned by reads of an encrypted file whose content was modified.
+	ErrAuthentication = errors.New("zipfs: authentication of encrypted file failed")
+)
+
+// Open files encrypted with WinZip AES using password.
+func Password(password string) Option {
+	return PasswordFunc(func(name string) (string, error) { return password, nil })
+}
+
+// Open files encrypted with WinZip AES using the password returned by fn for their
+// path, any error it returns is returned by Open.
+func PasswordFunc(fn func(name string) (string, error)) Option {
+	return func(fs *zipFS) {
+		fs.password = fn
+	}
+}
+
+// Settings of a file encrypted with WinZip AES, from its extra field.
+type aesExtra struct {
+	// 1 for AE-1, which keeps the CRC32, 2 for AE-2, which does not.
+	version uint16
+	// Key length in bytes, 16, 24 or 32.
+	keyLen int
+	method uint16
+}
+
+func parseAESExtra(extra []byte) (*aesExtra, error) {
+	for len(extra) >= 4 {
+		tag, size := binary.LittleEndian.Uint16(extra), int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		if tag == winzipAESExtra && size >= 7 && string(extra[2:4]) == "AE" && extra[4] >= 1 && extra[4] <= 3 {
+			return &aesExtra{
+				version: binary.LittleEndian.Uint16(extra),
+				keyLen:  8 + 8*int(extra[4]),
+				method:  binary.LittleEndian.Uint16(extra[5:]),
+			}, nil
+		}
+		extra = extra[size:]
+	}
+	return nil, zip.ErrFormat
+}
+
+// Decrypt the data of the encrypted entry read from raw, returning the reader of its
+// compressed content, its compression method and the CRC32 to check.
+func (fs *zipFS) decrypt(e *zipEntry, raw io.Reader) (io.Reader, uint16, uint32, error) {
+	x, err := parseAESExtra(e.Extra)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if fs.password == nil {
+		return nil, 0, 0, ErrPassword
+	}
+	password, err := fs.password("/" + e.Name)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	saltLen := x.keyLen / 2
+	overhead := uint64(saltLen + 2 + winzipAESTagLen)
+	if e.CompressedSize64 < overhead {
+		return nil, 0, 0, zip.ErrFormat
+	}
+	header := make([]byte, saltLen+2)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, 0, 0, err
+	}
+	key := pbkdf2SHA1([]byte(password), header[:saltLen], winzipAESRounds, 2*x.keyLen+2)
+	if !hmac.Equal(key[2*x.keyLen:], header[saltLen:]) {
+		return nil, 0, 0, ErrPassword
+	}
+	block, err := aes.NewCipher(key[:x.keyLen])
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	crc := e.CRC32
+	if x.version == 2 {
+		crc = 0
+	}
+	return &aesReader{
+		r:     raw,
+		n:     e.CompressedSize64 - overhead,
+		block: block,
+		mac:   hmac.New(sha1.New, key[x.keyLen:2*x.keyLen]),
+	}, x.method, crc, nil
+}
+
+// Decrypts n bytes with AES in counter mode, counting from 1 in little endian, then
+// checks the HMAC-SHA1 of the encrypted bytes against the authentication code after them.
+type aesReader struct {
+	r       io.Reader
+	n       uint64
+	block   cipher.Block
+	mac     hash.Hash
+	counter [aes.BlockSize]byte
+	stream  [aes.BlockSize]byte
+	used    int
+	err     error
+}
+
+func (r *aesReader) Read(b []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if uint64(len(b)) > r.n {
+		b = b[:r.n]
+	}
+	n, err := r.r.Read(b)
+	r.n -= uint64(n)
+	r.mac.Write(b[:n])
+	for i := range b[:n] {
+		if r.used == 0 || r.used == aes.BlockSize {
+			r.next()
+		}
+		b[i] ^= r.stream[r.used]
+		r.used++
+	}
+	// Authenticate along with the last bytes, before they can be decompressed.
+	if r.n == 0 {
+		err = r.authenticate()
+	} else if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	r.err = err
+	return n, err
+}
+
+// Encrypt the next counter into the key stream.
+func (r *aesReader) next() {
+	for i := range r.counter {
+		r.counter[i]++
+		if r.counter[i] != 0 {
+			break
+		}
+	}
+	r.block.Encrypt(r.stream[:], r.counter[:])
+	r.used = 0
+}
+
+func (r *aesReader) authenticate() error {
+	tag := make([]byte, winzipAESTagLen)
+	if _, err := io.ReadFull(r.r, tag); err != nil {
+		return err
+	}
+	if !hmac.Equal(tag, r.mac.Sum(nil)[:winzipAESTagLen]) {
+		return ErrAuthentication
+	}
+	return io.EOF
+}
+
+// PBKDF2 from RFC 8018 with HMAC-SHA1.
+func pbkdf2SHA1(password, salt []byte, rounds, keyLen int) []byte {
+	prf := hmac.New(sha1.New, password)
+	key := make([]byte, 0, keyLen+sha1.Size)
+	var index [4]byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		binary.BigEndian.PutUint32(index[:], block)
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(index[:])
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < rounds; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
@@ -0,0 +1,118 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2SHA1(t *testing.T) {
+	// Test vectors from RFC 6070.
+	tests := []struct {
+		password, salt string
+		rounds         int
+		expected       string
+	}{
+		{"password", "salt", 1, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{"password", "salt", 4096, "4b007901b765489abead49d926f721d065a429c1"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"},
+		{"pass\x00word", "sa\x00lt", 4096, "56fa6aa75548099dcc37d7f03425e0c3"},
+	}
+	for _, test := range tests {
+		key := pbkdf2SHA1([]byte(test.password), []byte(test.salt), test.rounds, len(test.expected)/2)
+		if actual := hex.EncodeToString(key); actual != test.expected {
+			t.Errorf("%q %q %d: expected %s got %s", test.password, test.salt, test.rounds, test.expected, actual)
+		}
+	}
+}
+
+// testdata/aes.zip holds ae1-128.txt, stored and encrypted with AE-1 and AES-128, and
+// ae2-192.txt and dir/ae2-256.txt, deflated and encrypted with AE-2 and AES-192 and
+// AES-256, all with the password zipfs.
+var aesContents = map[string]string{
+	"/ae1-128.txt":     strings.Repeat("AE-1 with AES-128 over stored content.\n", 3),
+	"/ae2-192.txt":     strings.Repeat("AE-2 with AES-192 over deflated content.\n", 50),
+	"/dir/ae2-256.txt": strings.Repeat("AE-2 with AES-256 over deflated content.\n", 50),
+}
+
+func aesZip(t *testing.T) *bytes.Reader {
+	b, err := os.ReadFile("testdata/aes.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(b)
+}
+
+func TestZipFS_Password(t *testing.T) {
+	r := aesZip(t)
+	z, _ := zip.NewReader(r, r.Size())
+
+	for _, readerAt := range []io.ReaderAt{r, nil} {
+		for _, opts := range [][]Option{{Password("zipfs")}, {Password("zipfs"), Lazy(1)}} {
+			fs := NewZipFSWithReaderAt(z, readerAt, opts...)
+			for name, expected := range aesContents {
+				if content := readAll(t, fs, name); string(content) != expected {
+					t.Errorf("%s: expected %q got %q", name, expected, content)
+				}
+			}
+			if err := fs.(Verifier).Verify(); err != nil {
+				t.Errorf("Expected no corrupt files got %v", err)
+			}
+		}
+	}
+
+	var names []string
+	fs := NewZipFSWithReaderAt(z, r, PasswordFunc(func(name string) (string, error) {
+		names = append(names, name)
+		if name == "/ae1-128.txt" {
+			return "wrong", nil
+		}
+		return "zipfs", nil
+	}))
+	if _, err := fs.Open("/ae1-128.txt"); err != ErrPassword {
+		t.Errorf("Expected %v got %v", ErrPassword, err)
+	}
+	readAll(t, fs, "/dir/ae2-256.txt")
+	if expected := []string{"/ae1-128.txt", "/dir/ae2-256.txt"}; strings.Join(names, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected passwords asked for %v got %v", expected, names)
+	}
+
+	if _, err := NewZipFSWithReaderAt(z, r).Open("/ae1-128.txt"); err != ErrPassword {
+		t.Errorf("Expected %v without a password got %v", ErrPassword, err)
+	}
+	failed := errors.New("no password")
+	fs = NewZipFSWithReaderAt(z, r, PasswordFunc(func(name string) (string, error) { return "", failed }))
+	if _, err := fs.Open("/ae1-128.txt"); err != failed {
+		t.Errorf("Expected %v got %v", failed, err)
+	}
+}
+
+func TestZipFS_PasswordAuthentication(t *testing.T) {
+	r := aesZip(t)
+	b := make([]byte, r.Size())
+	r.ReadAt(b, 0)
+
+	z, _ := zip.NewReader(r, r.Size())
+	for _, f := range z.File {
+		offset, _ := f.DataOffset()
+		tampered := append([]byte{}, b...)
+		// Flip a bit of the last encrypted byte, before the authentication code.
+		tampered[offset+int64(f.CompressedSize64)-11] ^= 1
+		tr := bytes.NewReader(tampered)
+		tz, _ := zip.NewReader(tr, tr.Size())
+
+		file, err := NewZipFSWithReaderAt(tz, tr, Password("zipfs")).Open("/" + f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadAll(file); err != ErrAuthentication {
+			t.Errorf("%s: expected %v got %v", f.Name, ErrAuthentication, err)
+		}
+		file.Close()
+	}
+}
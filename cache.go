@@ -0,0 +1,78 @@
+package zipfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// Keep the content of compressed files of up to maxFileSize bytes in memory once read,
+// up to maxBytes in total, evicting the least recently used. Files served from the cache
+// can seek.
+func Cache(maxBytes, maxFileSize int64) Option {
+	return func(fs *zipFS) {
+		fs.cache = newLRU(maxBytes)
+		fs.cacheFileSize = maxFileSize
+	}
+}
+
+// Statistics of the cache of a file system, see Cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Files in the cache, and their total size.
+	Files int
+	Bytes int64
+}
+
+// CacheReporter is implemented by file systems that report the statistics of their cache.
+type CacheReporter interface {
+	CacheStats() CacheStats
+}
+
+func (fs *zipFS) CacheStats() CacheStats {
+	stats := CacheStats{
+		Hits:   atomic.LoadUint64(&fs.cacheHits),
+		Misses: atomic.LoadUint64(&fs.cacheMisses),
+	}
+	if fs.cache != nil {
+		stats.Files, stats.Bytes = fs.cache.stats()
+	}
+	return stats
+}
+
+// Return the content of the entry from the cache, reading it whole when it is not there.
+func (fs *zipFS) cached(entry *zipEntry) ([]byte, error) {
+	if b, ok := fs.cache.Get(entry.Name); ok {
+		atomic.AddUint64(&fs.cacheHits, 1)
+		return b.([]byte), nil
+	}
+	atomic.AddUint64(&fs.cacheMisses, 1)
+
+	rc, err := fs.openEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	b := make([]byte, 0, entry.UncompressedSize64)
+	buf := bytes.NewBuffer(b)
+	if _, err := io.Copy(buf, &limitedReader{ReadCloser: rc, remaining: entry.UncompressedSize64}); err != nil {
+		return nil, err
+	}
+	fs.cache.Add(entry.Name, buf.Bytes(), int64(buf.Len()))
+	return buf.Bytes(), nil
+}
+
+type cachedFile struct {
+	*bytes.Reader
+	zipFile *zipEntry
+}
+
+func (f *cachedFile) Close() error               { return nil }
+func (f *cachedFile) Stat() (os.FileInfo, error) { return f.zipFile.FileInfo(), nil }
+
+func (f *cachedFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, errors.New("not a directory")
+}
@@ -0,0 +1,42 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestZipFS_Cache(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for name, size := range map[string]int{"app.css": 100, "app.js": 200, "video.mp4": 5000} {
+		f, _ := w.Create(name)
+		f.Write(bytes.Repeat([]byte(name), size/len(name)+1)[:size])
+	}
+	w.Close()
+	z, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	fs := NewZipFS(z, Cache(250, 1000))
+
+	for _, name := range []string{"/app.css", "/app.css", "/app.js", "/app.css", "/video.mp4", "/video.mp4"} {
+		readAll(t, fs, name)
+	}
+	// app.js evicts app.css, which evicts app.js again, video.mp4 is never cached.
+	expected := CacheStats{Hits: 1, Misses: 3, Files: 1, Bytes: 100}
+	if actual := fs.(CacheReporter).CacheStats(); actual != expected {
+		t.Errorf("Expected %+v got %+v", expected, actual)
+	}
+
+	file := Must(fs.Open("/app.css"))
+	file.Seek(91, io.SeekStart)
+	if b, _ := io.ReadAll(file); string(b) != "app.cssap" {
+		t.Errorf("Expected app.cssap got %q", b)
+	}
+	if fi, _ := file.Stat(); fi.Name() != "app.css" || fi.Size() != 100 {
+		t.Errorf("Expected app.css info got %s %d", fi.Name(), fi.Size())
+	}
+
+	if stats := NewZipFS(z).(CacheReporter).CacheStats(); stats != (CacheStats{}) {
+		t.Errorf("Expected no statistics without a cache got %+v", stats)
+	}
+}
@@ -0,0 +1,104 @@
+package zipfs
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// Look up paths ignoring case, using Unicode simple case folding. Paths that only
+// differ by case are reported as collisions, the first of them in path order is the
+// one that is opened.
+func CaseInsensitive() Option {
+	return func(fs *zipFS) {
+		fs.folded = map[string]string{}
+	}
+}
+
+// Index the paths by their case folded form, reporting the paths that collide.
+func (fs *zipFS) foldIndex() {
+	collisions := map[string]int{}
+	for _, n := range fs.trie.sorted() {
+		folded := foldCase(n.key)
+		canonical, ok := fs.folded[folded]
+		if !ok {
+			fs.folded[folded] = n.key
+			continue
+		}
+		i, ok := collisions[folded]
+		if !ok {
+			i = len(fs.report.Collisions)
+			collisions[folded] = i
+			fs.report.Collisions = append(fs.report.Collisions, []string{canonical})
+		}
+		fs.report.Collisions[i] = append(fs.report.Collisions[i], n.key)
+	}
+}
+
+// Return the indexed path for name, which differs when looking up ignoring case or
+// normalising names.
+func (fs *zipFS) canonical(name string) (string, bool) {
+	if fs.normalize {
+		name = nfc(name)
+	}
+	if _, ok := fs.trie.Find(name); ok || fs.folded == nil {
+		return name, ok
+	}
+	canonical, ok := fs.folded[foldCase(name)]
+	return canonical, ok
+}
+
+func foldCase(s string) string {
+	return strings.Map(foldRune, s)
+}
+
+// Map the rune to the smallest rune in its case folding orbit.
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// Redirect requests for paths that the case insensitive file system opens under a
+// different casing to the casing in the archive, serving every other request with h.
+func CanonicalCaseHandler(fileSystem http.FileSystem, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fs, ok := fileSystem.(*zipFS)
+		if !ok || fs.ready() != nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		name := path.Clean("/" + r.URL.Path)
+		canonical, ok := fs.canonical(name)
+		if !ok || canonical == name {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		// Relative to the requested directory, like http.FileServer redirects, so that it
+		// still works when the handler is mounted with http.StripPrefix.
+		depth := strings.Count(name, "/") - 1
+		if strings.HasSuffix(r.URL.Path, "/") && canonical != "/" {
+			canonical += "/"
+			depth++
+		}
+		location := strings.Repeat("../", depth)
+		if depth == 0 {
+			location = "./"
+		}
+		location += (&url.URL{Path: canonical[1:]}).EscapedPath()
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		// Not http.Redirect, it would make the location absolute again.
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,113 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func zipOf(names ...string) *zip.Reader {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, name := range names {
+		f, _ := w.Create(name)
+		f.Write([]byte(name))
+	}
+	w.Close()
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		panic(err)
+	}
+	return z
+}
+
+func TestZipFS_CaseInsensitive(t *testing.T) {
+	z := zipOf("Assets/Logo.PNG", "Docs/Readme.md", "docs/readme.md", "Straße.txt", "ΣΊΣΥΦΟΣ.txt")
+	fs := NewZipFS(z, CaseInsensitive())
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"/assets/logo.png", "Logo.PNG"},
+		{"/ASSETS/LOGO.png", "Logo.PNG"},
+		{"/Assets", "Assets"},
+		{"/straSSe.txt", ""},
+		{"/STRAßE.TXT", "Straße.txt"},
+		{"/σίσυφος.txt", "ΣΊΣΥΦΟΣ.txt"},
+		{"/DOCS/README.MD", "Readme.md"},
+		{"/docs/readme.md", "readme.md"},
+	}
+	for _, test := range tests {
+		f, err := fs.Open(test.name)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("Open(%q): expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Open(%q): %v", test.name, err)
+			continue
+		}
+		if fi, _ := f.Stat(); fi.Name() != test.expected {
+			t.Errorf("Open(%q): expected %s got %s", test.name, test.expected, fi.Name())
+		}
+	}
+
+	expected := [][]string{{"/Docs", "/docs"}, {"/Docs/Readme.md", "/docs/readme.md"}}
+	if actual := fs.(Reporter).Report().Collisions; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected collisions %v got %v", expected, actual)
+	}
+
+	if _, err := NewZipFS(z).Open("/assets/logo.png"); err == nil {
+		t.Error("Expected case sensitive lookups by default")
+	}
+}
+
+func TestCanonicalCaseHandler(t *testing.T) {
+	fs := NewZipFS(zipOf("Assets/Logo.PNG", "Docs/My File?#.txt", "Top.txt"), CaseInsensitive())
+	handler := CanonicalCaseHandler(fs, http.FileServer(fs))
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+	mux.Handle("/static/", http.StripPrefix("/static", handler))
+
+	tests := []struct {
+		url      string
+		code     int
+		location string
+	}{
+		{"/assets/logo.png?v=1", http.StatusMovedPermanently, "../Assets/Logo.PNG?v=1"},
+		{"/ASSETS/", http.StatusMovedPermanently, "../Assets/"},
+		{"/top.txt", http.StatusMovedPermanently, "./Top.txt"},
+		{"/docs/my%20file%3F%23.txt", http.StatusMovedPermanently, "../Docs/My%20File%3F%23.txt"},
+		{"/static/assets/logo.png", http.StatusMovedPermanently, "../Assets/Logo.PNG"},
+		{"/static/docs/MY%20FILE%3F%23.TXT", http.StatusMovedPermanently, "../Docs/My%20File%3F%23.txt"},
+		{"/Assets/Logo.PNG", http.StatusOK, ""},
+		{"/missing", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+		if w.Code != test.code || w.Header().Get("Location") != test.location {
+			t.Errorf("GET %s: expected %d %q got %d %q", test.url, test.code, test.location, w.Code, w.Header().Get("Location"))
+			continue
+		}
+		if test.location == "" {
+			continue
+		}
+		// Following the redirect serves the file.
+		location, _ := url.Parse(test.location)
+		next, _ := url.Parse(test.url)
+		next = next.ResolveReference(location)
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", next.String(), nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s: expected %d following to %s got %d", test.url, http.StatusOK, next, w.Code)
+		}
+	}
+}
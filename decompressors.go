@@ -0,0 +1,73 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"compress/bzip2"
+	"compress/flate"
+	"io"
+	"strconv"
+)
+
+// Compression methods from the zip specification, along with the ones archive/zip knows.
+const (
+	Bzip2     uint16 = 12
+	LZMA      uint16 = 14
+	Zstandard uint16 = 93
+	XZ        uint16 = 95
+)
+
+var methodNames = map[uint16]string{
+	zip.Store:   "store",
+	zip.Deflate: "deflate",
+	9:           "deflate64",
+	Bzip2:       "bzip2",
+	LZMA:        "lzma",
+	Zstandard:   "zstd",
+	XZ:          "xz",
+	98:          "ppmd",
+	winzipAES:   "winzip aes",
+}
+
+// Decompress the files compressed with method using d, in place of the decompressor of
+// archive/zip or this package for it. Bzip2 is supported without one.
+func Decompressor(method uint16, d zip.Decompressor) Option {
+	return func(fs *zipFS) {
+		fs.decompressors[method] = d
+	}
+}
+
+// Error returned when opening a file compressed with a method that has no decompressor,
+// it matches zip.ErrAlgorithm with errors.Is.
+type UnsupportedMethodError struct {
+	Method uint16
+}
+
+func (e *UnsupportedMethodError) Error() string {
+	method := strconv.Itoa(int(e.Method))
+	if name, ok := methodNames[e.Method]; ok {
+		method += " (" + name + ")"
+	}
+	return "zipfs: unsupported compression method " + method
+}
+
+func (e *UnsupportedMethodError) Is(target error) bool { return target == zip.ErrAlgorithm }
+
+func defaultDecompressors() map[uint16]zip.Decompressor {
+	return map[uint16]zip.Decompressor{
+		Bzip2: func(r io.Reader) io.ReadCloser { return io.NopCloser(bzip2.NewReader(r)) },
+	}
+}
+
+// Decompressor for method, nil when there is none.
+func (fs *zipFS) decompressor(method uint16) zip.Decompressor {
+	if d, ok := fs.decompressors[method]; ok {
+		return d
+	}
+	switch method {
+	case zip.Store:
+		return io.NopCloser
+	case zip.Deflate:
+		return flate.NewReader
+	}
+	return nil
+}
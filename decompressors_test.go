@@ -0,0 +1,65 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestZipFS_Bzip2(t *testing.T) {
+	// testdata/bzip2.zip holds bzip2.txt compressed with bzip2.
+	b, err := os.ReadFile("testdata/bzip2.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(b)
+	z, _ := zip.NewReader(r, r.Size())
+
+	expected := strings.Repeat("Compressed with bzip2.\n", 40)
+	for _, readerAt := range []io.ReaderAt{r, nil} {
+		if content := readAll(t, NewZipFSWithReaderAt(z, readerAt), "/bzip2.txt"); string(content) != expected {
+			t.Errorf("Expected %q got %q", expected, content)
+		}
+	}
+}
+
+func TestZipFS_Decompressor(t *testing.T) {
+	// Stand in for zstd, storing the content reversed.
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, _ := w.CreateRaw(&zip.FileHeader{
+		Name:               "reversed.txt",
+		Method:             Zstandard,
+		CompressedSize64:   5,
+		UncompressedSize64: 5,
+	})
+	io.WriteString(f, "olleh")
+	w.Close()
+	r := bytes.NewReader(buf.Bytes())
+	z, _ := zip.NewReader(r, r.Size())
+
+	_, err := NewZipFSWithReaderAt(z, r).Open("/reversed.txt")
+	if !errors.Is(err, zip.ErrAlgorithm) {
+		t.Errorf("Expected %v got %v", zip.ErrAlgorithm, err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "93 (zstd)") {
+		t.Errorf("Expected the method named in %v", err)
+	}
+
+	reverse := Decompressor(Zstandard, func(r io.Reader) io.ReadCloser {
+		b, _ := io.ReadAll(r)
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+		return io.NopCloser(bytes.NewReader(b))
+	})
+	for _, readerAt := range []io.ReaderAt{r, nil} {
+		if content := readAll(t, NewZipFSWithReaderAt(z, readerAt, reverse), "/reversed.txt"); string(content) != "hello" {
+			t.Errorf("Expected hello got %q", content)
+		}
+	}
+}
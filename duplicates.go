@@ -0,0 +1,33 @@
+package zipfs
+
+import (
+	"strconv"
+)
+
+// Which of the entries sharing a name is indexed, as happens after appending to an archive.
+type DuplicatePolicy int
+
+const (
+	// Index the last entry, as extracting the archive would leave it, the default.
+	LastDuplicateWins DuplicatePolicy = iota
+	// Index the first entry.
+	FirstDuplicateWins
+	// Fail to index the archive.
+	RejectDuplicates
+)
+
+// Set the policy for entries sharing a name, which are reported unless rejected.
+func Duplicates(policy DuplicatePolicy) Option {
+	return func(fs *zipFS) {
+		fs.duplicatePolicy = policy
+	}
+}
+
+// Error returned when indexing an archive with entries sharing a name that are rejected.
+type DuplicateNameError struct {
+	Name string
+}
+
+func (e *DuplicateNameError) Error() string {
+	return "zipfs: duplicate entry name " + strconv.Quote(e.Name)
+}
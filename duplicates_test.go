@@ -0,0 +1,64 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// Creates an archive with a.txt and b/c.txt appended twice, with differing content.
+func duplicatesZip() *zip.Reader {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, content := range []string{"first", "last"} {
+		for _, name := range []string{"a.txt", "b/", "b/c.txt"} {
+			f, _ := w.Create(name)
+			io.WriteString(f, content)
+		}
+	}
+	w.Close()
+	z, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	return z
+}
+
+func TestZipFS_Duplicates(t *testing.T) {
+	tests := []struct {
+		policy   DuplicatePolicy
+		expected string
+	}{
+		{LastDuplicateWins, "last"},
+		{FirstDuplicateWins, "first"},
+	}
+	for _, test := range tests {
+		for _, lazy := range []bool{false, true} {
+			opts := []Option{Duplicates(test.policy)}
+			if lazy {
+				opts = append(opts, Lazy(10))
+			}
+			fs := NewZipFS(duplicatesZip(), opts...)
+
+			for _, name := range []string{"/a.txt", "/b/c.txt"} {
+				if content := readAll(t, fs, name); string(content) != test.expected {
+					t.Errorf("Policy %d: expected %s got %s", test.policy, test.expected, content)
+				}
+			}
+			for name, count := range map[string]int{"/": 2, "/b": 1} {
+				dir, _ := fs.Open(name)
+				if infos, _ := dir.Readdir(-1); len(infos) != count {
+					t.Errorf("Policy %d: expected %d entries in %s got %d", test.policy, count, name, len(infos))
+				}
+			}
+			expected := []string{"/a.txt", "/b", "/b/c.txt"}
+			if actual := fs.(Reporter).Report().Duplicates; !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Policy %d: expected duplicates %v got %v", test.policy, expected, actual)
+			}
+		}
+	}
+
+	_, err := OpenZipFS(duplicatesZip(), nil, Duplicates(RejectDuplicates))
+	if e, ok := err.(*DuplicateNameError); !ok || e.Name != "a.txt" {
+		t.Errorf("Expected DuplicateNameError got %v", err)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"runtime"
 )
 
+var errNoDirectoryEnd = errors.New("could not locate zip file, no end-of-central-directory signature found")
+
 func binself() (*os.File, error) {
 	if runtime.GOOS != "windows" {
 		return os.Open(os.Args[0])
@@ -24,6 +26,23 @@ func binself() (*os.File, error) {
 
 // Tries to get the zip archive, that is embedded inside the running application.
 func GetEmbeddedZip() (*zip.Reader, io.ReaderAt, error) {
+	rr, bin, err := embeddedZip()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := zip.NewReader(rr, rr.Size())
+	if err != nil {
+		bin.Close()
+		return nil, nil, err
+	}
+
+	return r, rr, err
+}
+
+// Locates the zip archive appended to the running application, without reading its
+// central directory.
+func embeddedZip() (*io.SectionReader, *os.File, error) {
 	bin, err := binself()
 	if err != nil {
 		return nil, nil, err
@@ -34,32 +53,88 @@ func GetEmbeddedZip() (*zip.Reader, io.ReaderAt, error) {
 		return nil, nil, err
 	}
 
-	n := int64(65 * 1024)
 	size := fi.Size()
+	end, err := readDirectoryEnd(bin, size)
+	if err != nil {
+		bin.Close()
+		return nil, nil, err
+	}
+	if end.base < 0 {
+		bin.Close()
+		return nil, nil, zip.ErrFormat
+	}
+
+	return io.NewSectionReader(bin, end.base, size-end.base), bin, nil
+}
+
+type directoryEnd struct {
+	// Offset of the record and of the start of the archive from the start of the reader,
+	// they differ from the offsets recorded in the archive when it is appended to another file.
+	offset    int64
+	base      int64
+	records   uint64
+	dirSize   int64
+	dirOffset int64
+	comment   []byte
+}
+
+// Reads the end of central directory record from the last 65KiB of the size bytes of r,
+// following it to the zip64 record when the archive needs one.
+func readDirectoryEnd(r io.ReaderAt, size int64) (*directoryEnd, error) {
+	n := int64(65 * 1024)
 	if size < n {
 		n = size
 	}
 	buf := make([]byte, n)
-	_, err = io.ReadAtLeast(io.NewSectionReader(bin, size-n, n), buf, len(buf))
+	_, err := io.ReadAtLeast(io.NewSectionReader(r, size-n, n), buf, len(buf))
 	if err != nil {
-		bin.Close()
-		return nil, nil, err
+		return nil, err
 	}
 	o := int64(findSignatureInBlock(buf))
 	if o < 0 {
-		bin.Close()
-		return nil, nil, errors.New("could not locate zip file, no end-of-central-directory signature found")
+		return nil, errNoDirectoryEnd
 	}
-	cdirsize := int64(binary.LittleEndian.Uint32(buf[o+12:]))
-	cdiroff := int64(binary.LittleEndian.Uint32(buf[o+16:]))
-	zipsize := cdiroff + cdirsize + (int64(len(buf)) - o)
+	commentLen := int64(binary.LittleEndian.Uint16(buf[o+20:]))
 
-	rr := io.NewSectionReader(bin, size-zipsize, zipsize)
-	r, err := zip.NewReader(rr, zipsize)
-	if err != nil {
-		bin.Close()
-		return nil, nil, err
+	end := &directoryEnd{
+		offset:    size - n + o,
+		records:   uint64(binary.LittleEndian.Uint16(buf[o+10:])),
+		dirSize:   int64(binary.LittleEndian.Uint32(buf[o+12:])),
+		dirOffset: int64(binary.LittleEndian.Uint32(buf[o+16:])),
+		comment:   buf[o+directoryEndLen : o+directoryEndLen+commentLen],
+	}
+	end.base = end.offset - end.dirSize - end.dirOffset
+	if end.records == 0xffff || end.dirSize == 0xffffffff || end.dirOffset == 0xffffffff {
+		if err := end.readZip64(r); err != nil {
+			return nil, err
+		}
 	}
+	return end, nil
+}
 
-	return r, rr, err
+const (
+	directory64LocLen = 20
+	directory64EndLen = 56
+)
+
+// Reads the sizes and offsets from the zip64 end of central directory record, which is
+// expected to be followed by its locator right before the end of central directory record.
+func (end *directoryEnd) readZip64(r io.ReaderAt) error {
+	buf := make([]byte, directory64EndLen+directory64LocLen)
+	at := end.offset - int64(len(buf))
+	if at < 0 {
+		return zip.ErrFormat
+	}
+	if _, err := r.ReadAt(buf, at); err != nil {
+		return err
+	}
+	loc := buf[directory64EndLen:]
+	if binary.LittleEndian.Uint32(buf) != 0x06064b50 || binary.LittleEndian.Uint32(loc) != 0x07064b50 {
+		return zip.ErrFormat
+	}
+	end.records = binary.LittleEndian.Uint64(buf[32:])
+	end.dirSize = int64(binary.LittleEndian.Uint64(buf[40:]))
+	end.dirOffset = int64(binary.LittleEndian.Uint64(buf[48:]))
+	end.base = at - int64(binary.LittleEndian.Uint64(loc[8:]))
+	return nil
 }
@@ -0,0 +1,117 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// A file in the archive, either from the central directory or loaded from an index.
+type zipEntry struct {
+	*zip.FileHeader
+	// The file in the central directory, nil when loaded from an index.
+	file *zip.File
+	// Offset of the file data in the archive, -1 when it has to be read from the local header.
+	offset int64
+}
+
+func newZipEntries(files []*zip.File) []*zipEntry {
+	entries := make([]*zipEntry, len(files))
+	for i, f := range files {
+		entries[i] = &zipEntry{FileHeader: &f.FileHeader, file: f, offset: -1}
+	}
+	return entries
+}
+
+func (e *zipEntry) dataOffset() (int64, error) {
+	if e.offset < 0 && e.file != nil {
+		return e.file.DataOffset()
+	}
+	return e.offset, nil
+}
+
+// Read the file data as stored in the archive from r, or from the zip reader when r is nil.
+func (e *zipEntry) raw(r io.ReaderAt) (io.Reader, error) {
+	if r == nil && e.file != nil {
+		return e.file.OpenRaw()
+	}
+	offset, err := e.dataOffset()
+	if err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(r, offset, int64(e.CompressedSize64)), nil
+}
+
+// Open the file for reading its decompressed content, which is checked against the size
+// and CRC32 of the header when the end is reached.
+func (fs *zipFS) openEntry(e *zipEntry) (io.ReadCloser, error) {
+	// Leave the methods without a decompressor of our own to archive/zip.
+	if e.file != nil && e.Method != winzipAES && fs.decompressors[e.Method] == nil {
+		rc, err := e.file.Open()
+		if err == zip.ErrAlgorithm {
+			err = &UnsupportedMethodError{Method: e.Method}
+		}
+		return rc, err
+	}
+	raw, err := e.raw(fs.readerAt)
+	if err != nil {
+		return nil, err
+	}
+
+	method, crc := e.Method, e.CRC32
+	if e.Method == winzipAES {
+		if raw, method, crc, err = fs.decrypt(e, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	d := fs.decompressor(method)
+	if d == nil {
+		return nil, &UnsupportedMethodError{Method: method}
+	}
+	rc := d(raw)
+	cr := &checksumReader{ReadCloser: rc, hash: crc32.NewIEEE(), size: e.UncompressedSize64, crc: crc}
+	if e.Method == winzipAES {
+		cr.src = raw
+	}
+	return cr, nil
+}
+
+type checksumReader struct {
+	io.ReadCloser
+	hash  hash.Hash32
+	nread uint64
+	size  uint64
+	// Expected CRC32, 0 when it is not checked.
+	crc uint32
+	// Source of the decompressor, read to the end once it is done or fails, when set.
+	src io.Reader
+	err error
+}
+
+func (r *checksumReader) Read(b []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.ReadCloser.Read(b)
+	r.hash.Write(b[:n])
+	r.nread += uint64(n)
+	if r.nread > r.size {
+		err = zip.ErrFormat
+	} else if err == io.EOF {
+		if r.nread != r.size {
+			err = io.ErrUnexpectedEOF
+		} else if r.crc != 0 && r.hash.Sum32() != r.crc {
+			err = zip.ErrChecksum
+		}
+	}
+	// Errors of the source, such as failing authentication, explain any other.
+	if err != nil && r.src != nil {
+		if _, serr := io.Copy(io.Discard, r.src); serr != nil {
+			err = serr
+		}
+	}
+	r.err = err
+	return n, err
+}
@@ -0,0 +1,212 @@
+//go:build ignore
+// +build ignore
+
+// Generates nfc_tables.go from the Unicode Character Database and the Microsoft
+// mapping of code page 437, published by the Unicode Consortium. Run with go generate,
+// or with -ucd and -cp437 pointing to local copies of the files.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const unicodeVersion = "14.0.0"
+
+var (
+	ucd    = flag.String("ucd", "https://www.unicode.org/Public/"+unicodeVersion+"/ucd/", "URL or directory of the Unicode Character Database")
+	cp437  = flag.String("cp437", "https://www.unicode.org/Public/MAPPINGS/VENDORS/MICSFT/PC/CP437.TXT", "URL or path of the mapping of code page 437")
+	output = flag.String("o", "nfc_tables.go", "file to write")
+)
+
+func main() {
+	flag.Parse()
+
+	type decomposition struct {
+		r     rune
+		runes []rune
+	}
+	var decompositions []decomposition
+	classes := map[rune]uint8{}
+	eachLine(join(*ucd, "UnicodeData.txt"), func(fields []string) {
+		r := parseRune(fields[0])
+		class, err := strconv.ParseUint(fields[3], 10, 8)
+		if err != nil {
+			log.Fatalf("%s: %v", fields[0], err)
+		}
+		if class != 0 {
+			classes[r] = uint8(class)
+		}
+		// Compatibility decompositions start with a <tag>.
+		if fields[5] == "" || strings.HasPrefix(fields[5], "<") {
+			return
+		}
+		d := decomposition{r: r}
+		for _, s := range strings.Fields(fields[5]) {
+			d.runes = append(d.runes, parseRune(s))
+		}
+		if len(d.runes) > 2 {
+			log.Fatalf("%s: canonical decomposition of more than two runes", fields[0])
+		}
+		decompositions = append(decompositions, d)
+	})
+
+	excluded := map[rune]bool{}
+	eachLine(join(*ucd, "CompositionExclusions.txt"), func(fields []string) {
+		excluded[parseRune(fields[0])] = true
+	})
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by gen_nfc.go from the Unicode Character Database %s. DO NOT EDIT.\n\n", unicodeVersion)
+	buf.WriteString("package zipfs\n\n")
+
+	buf.WriteString("// Canonical decompositions, other than those of Hangul syllables, as the code point\n")
+	buf.WriteString("// followed by its decomposition. The second rune is 0 for singleton decompositions.\n")
+	buf.WriteString("var nfcDecompositions = [...][3]rune{\n")
+	sort.Slice(decompositions, func(i, j int) bool { return decompositions[i].r < decompositions[j].r })
+	var items []string
+	for _, d := range decompositions {
+		second := rune(0)
+		if len(d.runes) == 2 {
+			second = d.runes[1]
+		}
+		items = append(items, fmt.Sprintf("{0x%04X, 0x%04X, 0x%04X}", d.r, d.runes[0], second))
+	}
+	writeItems(buf, items, 4)
+
+	// Non-starter decompositions are excluded from composition along with the listed ones.
+	buf.WriteString("// Code points decomposing into two runes that are excluded from composition.\n")
+	buf.WriteString("var nfcExclusions = [...]rune{\n")
+	items = nil
+	for _, d := range decompositions {
+		if len(d.runes) == 2 && (excluded[d.r] || classes[d.r] != 0 || classes[d.runes[0]] != 0) {
+			items = append(items, fmt.Sprintf("0x%04X", d.r))
+		}
+	}
+	writeItems(buf, items, 8)
+
+	buf.WriteString("// Ranges of code points with a non-zero canonical combining class.\n")
+	buf.WriteString("var nfcCombiningClasses = [...]struct {\n\tlo, hi rune\n\tclass uint8\n}{\n")
+	var runes []rune
+	for r := range classes {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	items = nil
+	for i := 0; i < len(runes); {
+		j := i
+		for j+1 < len(runes) && runes[j+1] == runes[j]+1 && classes[runes[j+1]] == classes[runes[i]] {
+			j++
+		}
+		items = append(items, fmt.Sprintf("{0x%04X, 0x%04X, %d}", runes[i], runes[j], classes[runes[i]]))
+		i = j + 1
+	}
+	writeItems(buf, items, 4)
+
+	buf.WriteString("// Characters 0x80 to 0xFF of code page 437.\n")
+	buf.WriteString("var cp437 = [128]rune{\n")
+	table := make([]string, 128)
+	eachLine(*cp437, func(fields []string) {
+		c, err := strconv.ParseUint(strings.TrimPrefix(fields[0], "0x"), 16, 8)
+		if err != nil || c < 0x80 {
+			return
+		}
+		table[c-0x80] = fmt.Sprintf("0x%04X", parseRune(strings.TrimPrefix(fields[1], "0x")))
+	})
+	for c, item := range table {
+		if item == "" {
+			log.Fatalf("no mapping of 0x%02X in code page 437", c+0x80)
+		}
+	}
+	writeItems(buf, table, 8)
+
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, b, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeItems(buf *bytes.Buffer, items []string, perLine int) {
+	for i := 0; i < len(items); i += perLine {
+		end := i + perLine
+		if end > len(items) {
+			end = len(items)
+		}
+		fmt.Fprintf(buf, "\t%s,\n", strings.Join(items[i:end], ", "))
+	}
+	buf.WriteString("}\n\n")
+}
+
+func join(base, name string) string {
+	if strings.HasSuffix(base, "/") {
+		return base + name
+	}
+	return base + "/" + name
+}
+
+// Call fn with the fields of every line of the file at url, which are separated by ";"
+// or, in the code page mappings, by tabs. Comments and empty lines are skipped.
+func eachLine(url string, fn func(fields []string)) {
+	var r io.ReadCloser
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		resp, err := http.Get(url)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			log.Fatalf("%s: %s", url, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(url)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r = f
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		sep := ";"
+		if !strings.Contains(line, sep) {
+			sep = "\t"
+		}
+		fields := strings.Split(line, sep)
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		fn(fields)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("%s: %v", url, err)
+	}
+}
+
+func parseRune(s string) rune {
+	r, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		log.Fatalf("%q: %v", s, err)
+	}
+	return rune(r)
+}
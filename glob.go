@@ -0,0 +1,99 @@
+package zipfs
+
+import (
+	"path"
+	"strings"
+)
+
+// Globber is implemented by file systems that can match their paths against a pattern.
+type Globber interface {
+	// Glob returns the paths matching pattern in path order. The pattern uses the
+	// syntax of path.Match for each path segment, and a "**" segment matches
+	// zero or more segments. Patterns are rooted at "/", which only the pattern "/"
+	// matches itself.
+	Glob(pattern string) ([]string, error)
+}
+
+func (fs *zipFS) Glob(pattern string) ([]string, error) {
+	if fs.normalize {
+		pattern = nfc(pattern)
+	}
+	g, err := compileGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.ready(); err != nil {
+		return nil, err
+	}
+	return fs.glob(g), nil
+}
+
+func (fs *zipFS) glob(g *glob) []string {
+	var matches []string
+	for _, n := range fs.trie.prefixNodes(g.prefix) {
+		if g.match(n.key) {
+			matches = append(matches, n.key)
+		}
+	}
+	return matches
+}
+
+type glob struct {
+	// Literal part of the pattern before the first special character, every
+	// matching path starts with it.
+	prefix   string
+	segments []string
+}
+
+func compileGlob(pattern string) (*glob, error) {
+	pattern = "/" + strings.TrimLeft(pattern, "/")
+	g := &glob{
+		prefix:   pattern,
+		segments: strings.Split(pattern[1:], "/"),
+	}
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		g.prefix = pattern[:i]
+		// A "**" segment may match nothing, leaving the parent directory.
+		if strings.HasPrefix(pattern[i:], "**") && strings.HasSuffix(g.prefix, "/") {
+			g.prefix = g.prefix[:i-1]
+		}
+	}
+	for _, segment := range g.segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func (g *glob) match(name string) bool {
+	if name == "/" {
+		// Only the pattern "/" matches the root.
+		return len(g.segments) == 1 && g.segments[0] == ""
+	}
+	if !strings.HasPrefix(name, g.prefix) {
+		return false
+	}
+	return matchSegments(g.segments, strings.Split(name[1:], "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(name); i >= 0; i-- {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,81 @@
+package zipfs
+
+import (
+	"path"
+	"testing"
+)
+
+func TestZipFS_Glob(t *testing.T) {
+	fs := &zipFS{trie: newTrie()}
+	for _, key := range []string{
+		"/",
+		"/index.tmpl",
+		"/views",
+		"/views/index.tmpl",
+		"/views/layout.html",
+		"/views/partials/nav.tmpl",
+		"/views/partials/deep/footer.tmpl",
+		"/viewsextra/page.tmpl",
+		"/static/app.js",
+	} {
+		fs.trie.Add(key, nil)
+	}
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"/views/**/*.tmpl", []string{"/views/index.tmpl", "/views/partials/deep/footer.tmpl", "/views/partials/nav.tmpl"}},
+		{"views/*.tmpl", []string{"/views/index.tmpl"}},
+		{"/**/*.tmpl", []string{"/index.tmpl", "/views/index.tmpl", "/views/partials/deep/footer.tmpl", "/views/partials/nav.tmpl", "/viewsextra/page.tmpl"}},
+		{"/views/**", []string{"/views", "/views/index.tmpl", "/views/layout.html", "/views/partials/deep/footer.tmpl", "/views/partials/nav.tmpl"}},
+		{"/view?/*.html", []string{"/views/layout.html"}},
+		{"/[sv]*/*.js", []string{"/static/app.js"}},
+		{"/static/app.js", []string{"/static/app.js"}},
+		{"/nothing/**", nil},
+	}
+
+	for _, test := range tests {
+		actual, err := fs.Glob(test.pattern)
+		if err != nil {
+			t.Errorf("Glob(%q): %v", test.pattern, err)
+			continue
+		}
+		if len(actual) != len(test.expected) {
+			t.Errorf("Glob(%q): expected %v got %v", test.pattern, test.expected, actual)
+			continue
+		}
+		for i, key := range actual {
+			if key != test.expected[i] {
+				t.Errorf("Glob(%q): expected %v got %v", test.pattern, test.expected, actual)
+				break
+			}
+		}
+	}
+
+	if _, err := fs.Glob("/views/[a-"); err != path.ErrBadPattern {
+		t.Errorf("Expected ErrBadPattern got %v", err)
+	}
+}
+
+func TestCompileGlobPrefix(t *testing.T) {
+	tests := []struct {
+		pattern string
+		prefix  string
+	}{
+		{"/views/**/*.tmpl", "/views"},
+		{"/views/*.tmpl", "/views/"},
+		{"views/index*", "/views/index"},
+		{"/a/b/c.txt", "/a/b/c.txt"},
+		{"**", ""},
+	}
+	for _, test := range tests {
+		g, err := compileGlob(test.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.prefix != test.prefix {
+			t.Errorf("compileGlob(%q): expected prefix %q got %q", test.pattern, test.prefix, g.prefix)
+		}
+	}
+}
@@ -1 +1,3 @@
 module github.com/cjtoolkit/zipfs
+
+go 1.17
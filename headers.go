@@ -0,0 +1,175 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Path of the manifest of response headers in the archive. Each rule is a pattern, in
+// the syntax of Glob, followed by indented "Name: value" lines, for example
+//
+//	# Fingerprinted assets never change.
+//	/assets/**/*.*
+//	  Cache-Control: public, max-age=31536000, immutable
+//	/*.html
+//	  Content-Security-Policy: default-src 'self'
+//
+// A header set by several rules matching a path takes the value of the last of them.
+// Directories take the headers of their index.html, over those of the directory itself,
+// which the pattern "/" matches for the root. The manifest is not served.
+const HeadersManifest = "/_headers"
+
+// HeaderSource is implemented by file systems with response headers for their paths.
+type HeaderSource interface {
+	// Header returns the headers for the named path, nil when there are none.
+	Header(name string) http.Header
+}
+
+func (fs *zipFS) Header(name string) http.Header {
+	if fs.ready() != nil || fs.headers == nil {
+		return nil
+	}
+	name, ok := fs.canonical(path.Clean("/" + name))
+	if !ok {
+		return nil
+	}
+	header := fs.pathHeader(name)
+	node, _ := fs.trie.Find(name)
+	switch node.meta.(type) {
+	case *zipDir, *zipRoot:
+		index, ok := fs.canonical(path.Join(name, "index.html"))
+		if !ok {
+			break
+		}
+		if indexHeader := fs.pathHeader(index); indexHeader != nil {
+			merged := header.Clone()
+			if merged == nil {
+				merged = http.Header{}
+			}
+			for key, values := range indexHeader {
+				merged[key] = values
+			}
+			header = merged
+		}
+	}
+	return header
+}
+
+func (fs *zipFS) pathHeader(name string) http.Header {
+	if n, ok := fs.headers.Find(name); ok {
+		return n.meta.(http.Header)
+	}
+	return nil
+}
+
+type headerRule struct {
+	pattern *glob
+	header  http.Header
+}
+
+// Load the headers from the manifest in the archive, when there is one, matching the
+// patterns against the index once.
+func (fs *zipFS) loadHeaders() error {
+	b, ok, err := fs.readIndexed(HeadersManifest)
+	if !ok || err != nil {
+		return err
+	}
+	rules, err := parseHeaders(b)
+	if err != nil {
+		return &EntryError{Name: HeadersManifest, Err: err}
+	}
+	fs.hide(HeadersManifest)
+
+	headers := map[string]http.Header{}
+	for _, rule := range rules {
+		for _, name := range fs.glob(rule.pattern) {
+			header, ok := headers[name]
+			if !ok {
+				header = http.Header{}
+				headers[name] = header
+			}
+			for key, values := range rule.header {
+				header[key] = append([]string(nil), values...)
+			}
+		}
+	}
+	fs.headers = newTrie()
+	for name, header := range headers {
+		fs.headers.Add(name, header)
+	}
+	fs.headers.Sort()
+	return nil
+}
+
+// Remove the named file from the index and from the listing of its directory.
+func (fs *zipFS) hide(name string) {
+	fs.trie.Remove(name)
+	if fs.folded != nil && fs.folded[foldCase(name)] == name {
+		delete(fs.folded, foldCase(name))
+	}
+	node, ok := fs.trie.Find(path.Dir(name))
+	if !ok {
+		return
+	}
+	var dir *zipDir
+	switch d := node.meta.(type) {
+	case *zipDir:
+		dir = d
+	case *zipRoot:
+		dir = &d.zipDir
+	default:
+		return
+	}
+	files := make([]*zip.FileHeader, 0, len(dir.Files))
+	for _, fh := range dir.Files {
+		if "/"+strings.TrimRight(fh.Name, "/") != name {
+			files = append(files, fh)
+		}
+	}
+	dir.Files = files
+}
+
+func parseHeaders(b []byte) ([]*headerRule, error) {
+	var rules []*headerRule
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Text()
+		text := strings.TrimSpace(raw)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if indented := strings.TrimLeft(raw, " \t") != raw; !indented {
+			pattern, err := compileGlob(text)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			rules = append(rules, &headerRule{pattern: pattern, header: http.Header{}})
+			continue
+		}
+		i := strings.Index(text, ":")
+		if len(rules) == 0 || i <= 0 {
+			return nil, fmt.Errorf("line %d: expected a pattern or an indented header", line)
+		}
+		rules[len(rules)-1].header.Add(strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]))
+	}
+	return rules, scanner.Err()
+}
+
+// Set the headers that the file system has for the requested path, see HeadersManifest,
+// before serving the request with h.
+func HeaderHandler(fileSystem http.FileSystem, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if source, ok := fileSystem.(HeaderSource); ok {
+			for key, values := range source.Header(r.URL.Path) {
+				// Copied, so h adding to a header does not write to the one shared by requests.
+				w.Header()[key] = append([]string(nil), values...)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,114 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+const headersManifest = `# Fingerprinted assets never change.
+/assets/**/*.*
+  Cache-Control: public, max-age=31536000, immutable
+
+/*.html
+	Cache-Control: no-cache
+/api.json
+  Access-Control-Allow-Origin: *
+  vary: Origin
+  Vary: Accept
+  Vary: Cookie
+/
+  X-Frame-Options: DENY
+/**/*.html
+  Content-Security-Policy: default-src 'self'
+`
+
+func headersZipFS() http.FileSystem {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, name := range []string{"_headers", "index.html", "api.json", "docs/index.html", "assets/app.1234.js", "assets/img/logo.abcd.png", "assets/LICENSE"} {
+		f, _ := w.Create(name)
+		if name == "_headers" {
+			f.Write([]byte(headersManifest))
+		}
+	}
+	w.Close()
+	z, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	return NewZipFS(z)
+}
+
+func TestHeaderHandler(t *testing.T) {
+	fs := headersZipFS()
+	immutable := http.Header{"Cache-Control": {"public, max-age=31536000, immutable"}}
+	tests := map[string]http.Header{
+		"/index.html":               {"Cache-Control": {"no-cache"}, "Content-Security-Policy": {"default-src 'self'"}},
+		"/":                         {"Cache-Control": {"no-cache"}, "Content-Security-Policy": {"default-src 'self'"}, "X-Frame-Options": {"DENY"}},
+		"/docs/":                    {"Content-Security-Policy": {"default-src 'self'"}},
+		"/assets/":                  nil,
+		"/api.json":                 {"Access-Control-Allow-Origin": {"*"}, "Vary": {"Origin", "Accept", "Cookie"}},
+		"/assets/app.1234.js":       immutable,
+		"/assets/img/logo.abcd.png": immutable,
+		"/assets/LICENSE":           nil,
+		"/missing.html":             nil,
+	}
+	h := HeaderHandler(fs, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for name, expected := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", name, nil))
+		actual := rec.Header()
+		if expected == nil {
+			expected = http.Header{}
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: expected %v got %v", name, expected, actual)
+		}
+	}
+
+	// The manifest is not served.
+	if _, err := fs.Open(HeadersManifest); !os.IsNotExist(err) {
+		t.Errorf("Expected %s not to exist got %v", HeadersManifest, err)
+	}
+	infos, _ := Must(fs.Open("/")).Readdir(-1)
+	for _, fi := range infos {
+		if fi.Name() == "_headers" {
+			t.Errorf("Expected %s not to be listed", HeadersManifest)
+		}
+	}
+}
+
+// Handlers adding to the headers must not change them for other requests, run with -race.
+func TestHeaderHandler_Concurrent(t *testing.T) {
+	h := HeaderHandler(headersZipFS(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}))
+	expected := []string{"Origin", "Accept", "Cookie", "Accept-Encoding"}
+	done := make(chan bool)
+	for i := 0; i < 8; i++ {
+		go func() {
+			for j := 0; j < 100; j++ {
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, httptest.NewRequest("GET", "/api.json", nil))
+				if actual := rec.Header()["Vary"]; !reflect.DeepEqual(actual, expected) {
+					t.Errorf("expected %v got %v", expected, actual)
+					break
+				}
+			}
+			done <- true
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	for _, manifest := range []string{"  Cache-Control: no-cache\n", "/*.html\n  no header\n", "/[\n"} {
+		if _, err := parseHeaders([]byte(manifest)); err == nil {
+			t.Errorf("Expected an error parsing %q", manifest)
+		}
+	}
+}
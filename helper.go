@@ -2,6 +2,7 @@ package zipfs
 
 import (
 	"archive/zip"
+	"errors"
 	"io"
 	"log"
 	"net/http"
@@ -10,53 +11,110 @@ import (
 )
 
 // Initialise ZipFS based on given zip file name.
-// If the file does not exist, it will try to get the zip file that is embedded in the application itself.
-// If the application also does not have zip embedded it will panic.
-func InitZipFs(zipFileName string) http.FileSystem {
+// If the file does not exist or is not a zip file, it will try to get the zip file that is embedded in the application itself.
+// If the application also does not have zip embedded it will panic, as it does when the
+// zip file breaks the options, such as failing its signature or limits.
+func InitZipFs(zipFileName string, opts ...Option) http.FileSystem {
 	f, err := os.Open(zipFileName)
 	if err != nil {
-		return initEmbeddedZipFs()
+		return initEmbeddedZipFs(opts)
 	}
 	fi, err := f.Stat()
 	if err != nil {
 		f.Close()
-		return initEmbeddedZipFs()
+		return initEmbeddedZipFs(opts)
 	}
 
-	z, err := zip.NewReader(f, fi.Size())
+	fs, err := openZipFS(f, fi.Size(), zipFileName+IndexSuffix, opts)
 	if err == nil {
-		return NewZipFSWithReaderAt(z, f)
+		return fs
 	}
 
-	return initEmbeddedZipFs()
+	f.Close()
+	if !notZip(err) {
+		log.Panic(err)
+	}
+	return initEmbeddedZipFs(opts)
+}
+
+// Whether err is from opening something that is not a zip archive at all.
+func notZip(err error) bool {
+	return err == errNoDirectoryEnd || errors.Is(err, zip.ErrFormat)
 }
 
-func initEmbeddedZipFs() http.FileSystem {
-	z, r, err := GetEmbeddedZip()
+func initEmbeddedZipFs(opts []Option) http.FileSystem {
+	r, _, err := embeddedZip()
 	if err != nil {
 		log.Panic(err)
 	}
 
-	return NewZipFSWithReaderAt(z, r)
+	fs, err := openZipFS(r, r.Size(), "", opts)
+	if err != nil {
+		log.Panic(err)
+	}
+	return fs
 }
 
-// Init Zip FS from HTTP File, must be uncompressed. Does not support compressed files!
-func InitZipFsFromHttpFile(f http.File) http.FileSystem {
-	r, ok := f.(io.ReaderAt)
-	if !ok {
-		log.Panic("Does not implemented io.ReaderAt, must use uncompressed file. Does not support compressed files!")
-	}
+// Init Zip FS from HTTP File. Files that do not implement io.ReaderAt are read by seeking,
+// or read whole when they cannot seek either, such as compressed files, see Spill.
+func InitZipFsFromHttpFile(f http.File, opts ...Option) http.FileSystem {
 	fi, err := f.Stat()
 	if err != nil {
 		log.Panic(err)
 	}
 
-	z, err := zip.NewReader(r, fi.Size())
+	fs := newZipFS(nil, opts)
+	fs.readerAt, err = fs.httpFileReaderAt(f, fi.Size())
 	if err != nil {
 		log.Panic(err)
 	}
+	if err := fs.openArchive(fi.Size(), ""); err != nil {
+		log.Panic(err)
+	}
+	return fs
+}
+
+// Create a Zip File System over the size bytes of r, loading the entries from the index
+// file when it is present and up to date.
+func openZipFS(r io.ReaderAt, size int64, index string, opts []Option) (*zipFS, error) {
+	fs := newZipFS(r, opts)
+	if err := fs.openArchive(size, index); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
 
-	return NewZipFSWithReaderAt(z, r)
+// Open the archive of size bytes in the readerAt. Lazy file systems only check for the
+// end of central directory record here, leaving the rest until first use.
+func (fs *zipFS) openArchive(size int64, index string) error {
+	r := fs.readerAt
+	if fs.lazy || fs.limits.Entries > 0 {
+		end, err := readDirectoryEnd(r, size)
+		if err != nil {
+			return err
+		}
+		// Refuse before zip.NewReader allocates every entry.
+		if err := fs.limits.checkEntries(end.records); err != nil {
+			return err
+		}
+	}
+	return fs.open(func() ([]*zipEntry, error) {
+		if err := fs.verifySignature(r, size); err != nil {
+			return nil, err
+		}
+		// The signature covers the central directory, not the index, so a signed
+		// archive is always read from its central directory.
+		if index != "" && fs.publicKey == nil {
+			if entries, err := loadIndex(index, r, size); err == nil {
+				return entries, nil
+			}
+		}
+		z, err := zip.NewReader(r, size)
+		if err != nil {
+			return nil, err
+		}
+		return newZipEntries(z.File), nil
+	})
 }
 
 type fileSystemFunc func(name string) (http.File, error)
@@ -0,0 +1,101 @@
+package zipfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+)
+
+const defaultSpillMemory = 32 << 20
+
+// Error returned when a file has to be read whole, but it is larger than allowed by Spill.
+var ErrSpillLimit = errors.New("zipfs: file exceeds the spill limit")
+
+// Limit how files that can neither read at an offset nor seek, such as compressed files
+// of another zip file system, are read whole by InitZipFsFromHttpFile, as are compressed
+// nested zip files and gzip compressed tar archives. Files of up to maxMemory bytes are
+// kept in memory, 32MiB by default, and larger ones of up to maxSize bytes are written
+// to a temporary file. Zero maxSize, the default other than for tar archives, disables
+// temporary files.
+func Spill(maxMemory, maxSize int64) Option {
+	return func(fs *zipFS) {
+		fs.spillMemory = maxMemory
+		fs.spillSize = maxSize
+	}
+}
+
+// Reader at an offset of f, which is read whole when it cannot seek.
+func (fs *zipFS) httpFileReaderAt(f http.File, size int64) (io.ReaderAt, error) {
+	if r, ok := f.(io.ReaderAt); ok {
+		return r, nil
+	}
+	if _, err := f.Seek(0, io.SeekCurrent); err == nil {
+		return &seekReaderAt{r: f}, nil
+	}
+	r, _, err := fs.spill(f, size)
+	return r, err
+}
+
+// Read the size bytes of r whole, in memory or to a temporary file, see Spill. A negative
+// size is unknown, the size read is returned along with the reader.
+func (fs *zipFS) spill(r io.Reader, size int64) (io.ReaderAt, int64, error) {
+	if size <= fs.spillMemory {
+		b, err := io.ReadAll(io.LimitReader(r, fs.spillMemory+1))
+		if err != nil {
+			return nil, 0, err
+		}
+		if int64(len(b)) <= fs.spillMemory {
+			return bytes.NewReader(b), int64(len(b)), nil
+		}
+		if size >= 0 {
+			return nil, 0, ErrSpillLimit
+		}
+		r = io.MultiReader(bytes.NewReader(b), r)
+	}
+	if size > fs.spillSize {
+		return nil, 0, ErrSpillLimit
+	}
+
+	tmp, err := os.CreateTemp("", "zipfs-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	// The file system is never closed, so do not leave the file behind where it can be
+	// removed while open.
+	if runtime.GOOS != "windows" {
+		os.Remove(tmp.Name())
+	}
+	n, err := io.Copy(tmp, io.LimitReader(r, fs.spillSize+1))
+	if err == nil && n > fs.spillSize {
+		err = ErrSpillLimit
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, 0, err
+	}
+	return tmp, n, nil
+}
+
+// Reads at an offset by seeking before reading, one read at a time.
+type seekReaderAt struct {
+	mu sync.Mutex
+	r  io.ReadSeeker
+}
+
+func (r *seekReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(r.r, b)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,74 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+// Creates an archive holding inner.zip compressed with method, itself holding hello.txt.
+func nestedZip(method uint16) *bytes.Reader {
+	inner := &bytes.Buffer{}
+	w := zip.NewWriter(inner)
+	f, _ := w.Create("hello.txt")
+	f.Write([]byte("hello"))
+	w.Close()
+
+	outer := &bytes.Buffer{}
+	w = zip.NewWriter(outer)
+	f, _ = w.CreateHeader(&zip.FileHeader{Name: "inner.zip", Method: method})
+	f.Write(inner.Bytes())
+	w.Close()
+	return bytes.NewReader(outer.Bytes())
+}
+
+// Hides the ReadAt method of the file.
+type seekOnlyFile struct {
+	http.File
+}
+
+func TestInitZipFsFromHttpFile(t *testing.T) {
+	open := func(method uint16) http.File {
+		r := nestedZip(method)
+		z, _ := zip.NewReader(r, r.Size())
+		return Must(NewZipFSWithReaderAt(z, r).Open("/inner.zip"))
+	}
+
+	tests := []struct {
+		name string
+		file http.File
+		opts []Option
+	}{
+		{"read at", open(zip.Store), nil},
+		{"seeking", seekOnlyFile{open(zip.Store)}, nil},
+		{"in memory", open(zip.Deflate), nil},
+		{"temporary file", open(zip.Deflate), []Option{Spill(0, 1<<20)}},
+	}
+	for _, test := range tests {
+		fs := InitZipFsFromHttpFile(test.file, test.opts...)
+		if content := readAll(t, fs, "/hello.txt"); string(content) != "hello" {
+			t.Errorf("%s: expected hello got %q", test.name, content)
+		}
+	}
+
+	func() {
+		defer func() {
+			if err := recover(); err == nil {
+				t.Error("Expected a panic exceeding the spill limit")
+			}
+		}()
+		InitZipFsFromHttpFile(open(zip.Deflate), Spill(16, 0))
+	}()
+}
+
+func TestSeekReaderAt(t *testing.T) {
+	r := &seekReaderAt{r: bytes.NewReader([]byte("hello"))}
+	b := make([]byte, 3)
+	if n, err := r.ReadAt(b, 1); n != 3 || err != nil || string(b) != "ell" {
+		t.Errorf("Expected ell got %q %d %v", b[:n], n, err)
+	}
+	if n, err := r.ReadAt(b, 3); n != 2 || err == nil || string(b[:n]) != "lo" {
+		t.Errorf("Expected lo with an error got %q %d %v", b[:n], n, err)
+	}
+}
@@ -0,0 +1,106 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Index the files in a single pass, listing each entry in its parent directory as it
+// is seen. Directories without an entry of their own are synthesised from the paths
+// of their contents. Lazy file systems skip the listings and build them when opened.
+func (fs *zipFS) build(entries []*zipEntry) error {
+	root := &zipRoot{
+		zipDir: zipDir{Info: &zip.FileHeader{}},
+		Info:   zipRootInfo{time.Now()},
+	}
+	dirs := map[string]*zipDir{"/": &root.zipDir}
+
+	var dir func(name string) *zipDir
+	dir = func(name string) *zipDir {
+		if d, ok := dirs[name]; ok {
+			return d
+		}
+		d := &zipDir{Info: &zip.FileHeader{Name: name[1:] + "/", Modified: root.Info.t}}
+		d.Info.SetMode(os.ModeDir | 0755)
+		dirs[name] = d
+		parent := dir(path.Dir(name))
+		if !fs.lazy {
+			parent.Files = append(parent.Files, d.Info)
+		}
+		return d
+	}
+
+	if err := fs.limits.checkEntries(uint64(len(entries))); err != nil {
+		return err
+	}
+	var total uint64
+
+	// Position of each indexed name in its parent's listing, -1 for directories.
+	listed := map[string]int{}
+	duplicates := map[string]bool{}
+
+	for _, entry := range entries {
+		entry, err := fs.rename(entry)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+		if err := fs.limits.check(entry, &total); err != nil {
+			return err
+		}
+		name := "/" + strings.TrimRight(entry.Name, "/")
+
+		i, duplicate := listed[name]
+		if duplicate {
+			if fs.duplicatePolicy == RejectDuplicates {
+				return &DuplicateNameError{Name: entry.Name}
+			}
+			if !duplicates[name] {
+				duplicates[name] = true
+				fs.report.Duplicates = append(fs.report.Duplicates, name)
+			}
+			if fs.duplicatePolicy == FirstDuplicateWins {
+				continue
+			}
+		}
+
+		if entry.Mode().IsDir() {
+			*dir(name).Info = *entry.FileHeader
+			listed[name] = -1
+			continue
+		}
+		fs.trie.Add(name, entry)
+		parent := dir(path.Dir(name))
+		switch {
+		case fs.lazy:
+			listed[name] = -1
+		case duplicate && i >= 0:
+			parent.Files[i] = entry.FileHeader
+		default:
+			listed[name] = len(parent.Files)
+			parent.Files = append(parent.Files, entry.FileHeader)
+		}
+	}
+
+	for name, d := range dirs {
+		if name != "/" {
+			fs.trie.Add(name, d)
+		}
+	}
+	fs.trie.Add("/", root)
+	// Sorted once here, so opening files does not have to lock the index.
+	fs.trie.Sort()
+
+	if fs.folded != nil {
+		fs.foldIndex()
+	}
+	if err := fs.loadMIME(); err != nil {
+		return err
+	}
+	return fs.loadHeaders()
+}
@@ -0,0 +1,457 @@
+package zipfs
+
+import (
+	"bufio"
+	"compress/flate"
+	"io"
+	"sync"
+)
+
+// Decoder of deflate streams, RFC 1951, that can start at any block boundary given the
+// output before it. Unlike compress/flate, it tells where its blocks start.
+type inflater struct {
+	r io.ByteReader
+	// Bit buffer, least significant bit first, and the offset in bits of the next byte
+	// of r from the start of the stream.
+	bits   uint32
+	nbits  uint
+	offset int64
+
+	// The last 32KiB of output, have bytes of it written so far.
+	window [windowSize]byte
+	wpos   int
+	have   int
+	out    int64
+
+	state    int
+	final    bool
+	stored   int
+	lit      *huffman
+	dist     *huffman
+	copyLen  int
+	copyDist int
+	err      error
+
+	// Called before reading the header of each block, but the first.
+	onBlock func()
+}
+
+const windowSize = 1 << 15
+
+const (
+	stateHeader = iota
+	stateStored
+	stateHuffman
+)
+
+// Start decoding the stream in r at the block starting at the offset in bits, with
+// window the output before it, of which there are out bytes.
+func newInflater(r io.ReaderAt, size int64, offset int64, out int64, window []byte) *inflater {
+	f := &inflater{
+		r:      bufio.NewReader(io.NewSectionReader(r, offset/8, size-offset/8)),
+		offset: offset / 8 * 8,
+		out:    out,
+	}
+	f.have = copy(f.window[:], window[len(window)-minInt(len(window), windowSize):])
+	f.wpos = f.have % windowSize
+	if skip := uint(offset % 8); skip > 0 {
+		if f.need(skip) == nil {
+			f.consume(skip)
+		}
+	}
+	return f
+}
+
+// Offset in bits of the next unread bit of the stream.
+func (f *inflater) bitOffset() int64 { return f.offset - int64(f.nbits) }
+
+// The output before the current position, at most 32KiB of it.
+func (f *inflater) history() []byte {
+	b := make([]byte, 0, f.have)
+	start := (f.wpos - f.have + windowSize) % windowSize
+	if start+f.have > windowSize {
+		b = append(b, f.window[start:]...)
+		return append(b, f.window[:f.wpos]...)
+	}
+	return append(b, f.window[start:start+f.have]...)
+}
+
+func (f *inflater) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && f.err == nil {
+		switch {
+		case f.copyLen > 0:
+			for ; f.copyLen > 0 && n < len(p); f.copyLen-- {
+				p[n] = f.window[(f.wpos-f.copyDist)&(windowSize-1)]
+				f.emit(p[n])
+				n++
+			}
+		case f.state == stateHeader:
+			if f.final {
+				f.err = io.EOF
+			} else {
+				f.header()
+			}
+		case f.state == stateStored:
+			if f.stored == 0 {
+				f.state = stateHeader
+				continue
+			}
+			if f.err = f.need(8); f.err == nil {
+				p[n] = byte(f.bits)
+				f.consume(8)
+				f.emit(p[n])
+				f.stored--
+				n++
+			}
+		default:
+			f.symbol(p, &n)
+		}
+	}
+	if n > 0 && f.err == io.EOF {
+		return n, nil
+	}
+	return n, f.err
+}
+
+func (f *inflater) emit(b byte) {
+	f.window[f.wpos] = b
+	f.wpos = (f.wpos + 1) & (windowSize - 1)
+	if f.have < windowSize {
+		f.have++
+	}
+	f.out++
+}
+
+// Ensure there are at least n bits in the buffer.
+func (f *inflater) need(n uint) error {
+	for f.nbits < n {
+		b, err := f.r.ReadByte()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		f.bits |= uint32(b) << f.nbits
+		f.nbits += 8
+		f.offset += 8
+	}
+	return nil
+}
+
+func (f *inflater) consume(n uint) {
+	f.bits >>= n
+	f.nbits -= n
+}
+
+func (f *inflater) read(n uint) (int, error) {
+	if err := f.need(n); err != nil {
+		return 0, err
+	}
+	v := int(f.bits & (1<<n - 1))
+	f.consume(n)
+	return v, nil
+}
+
+func (f *inflater) corrupt() error {
+	return flate.CorruptInputError(f.bitOffset() / 8)
+}
+
+func (f *inflater) header() {
+	if f.onBlock != nil && (f.out > 0 || f.bitOffset() > 0) {
+		f.onBlock()
+	}
+	v, err := f.read(3)
+	if err != nil {
+		f.err = err
+		return
+	}
+	f.final = v&1 == 1
+	switch v >> 1 {
+	case 0:
+		// Stored blocks start at a byte boundary with their length and its complement.
+		f.consume(f.nbits % 8)
+		var length, nlength int
+		if length, f.err = f.read(16); f.err != nil {
+			return
+		}
+		if nlength, f.err = f.read(16); f.err != nil {
+			return
+		}
+		if length != ^nlength&0xffff {
+			f.err = f.corrupt()
+			return
+		}
+		f.stored = length
+		f.state = stateStored
+	case 1:
+		f.lit, f.dist = fixedHuffman()
+		f.state = stateHuffman
+	case 2:
+		f.err = f.dynamic()
+		f.state = stateHuffman
+	default:
+		f.err = f.corrupt()
+	}
+}
+
+// Order of the code length code lengths in the header of dynamic blocks.
+var codeOrder = [19]int{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+
+func (f *inflater) dynamic() error {
+	var counts [3]int
+	for i, n := range []uint{5, 5, 4} {
+		v, err := f.read(n)
+		if err != nil {
+			return err
+		}
+		counts[i] = v
+	}
+	nlit, ndist, ncode := counts[0]+257, counts[1]+1, counts[2]+4
+	if nlit > 286 || ndist > 30 {
+		return f.corrupt()
+	}
+
+	var lengths [286 + 30]uint8
+	for i := 0; i < ncode; i++ {
+		v, err := f.read(3)
+		if err != nil {
+			return err
+		}
+		lengths[codeOrder[i]] = uint8(v)
+	}
+	code, ok := newHuffman(lengths[:19])
+	if !ok {
+		return f.corrupt()
+	}
+
+	for i := 0; i < nlit+ndist; {
+		sym, err := f.decode(code)
+		if err != nil {
+			return err
+		}
+		if sym < 16 {
+			lengths[i] = uint8(sym)
+			i++
+			continue
+		}
+		var repeat int
+		var value uint8
+		switch sym {
+		case 16:
+			if i == 0 {
+				return f.corrupt()
+			}
+			value = lengths[i-1]
+			repeat, err = f.read(2)
+			repeat += 3
+		case 17:
+			repeat, err = f.read(3)
+			repeat += 3
+		default:
+			repeat, err = f.read(7)
+			repeat += 11
+		}
+		if err != nil {
+			return err
+		}
+		if i+repeat > nlit+ndist {
+			return f.corrupt()
+		}
+		for ; repeat > 0; repeat-- {
+			lengths[i] = value
+			i++
+		}
+	}
+	if lengths[256] == 0 {
+		return f.corrupt()
+	}
+	if f.lit, ok = newHuffman(lengths[:nlit]); !ok {
+		return f.corrupt()
+	}
+	if f.dist, ok = newHuffman(lengths[nlit : nlit+ndist]); !ok {
+		return f.corrupt()
+	}
+	return nil
+}
+
+var (
+	lengthBase  = [29]int{3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, 35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258}
+	lengthExtra = [29]uint{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	distBase    = [30]int{1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193, 257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577}
+	distExtra   = [30]uint{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+)
+
+// Decode the next symbol of a Huffman block, writing literals to p.
+func (f *inflater) symbol(p []byte, n *int) {
+	sym, err := f.decode(f.lit)
+	if err != nil {
+		f.err = err
+		return
+	}
+	switch {
+	case sym < 256:
+		p[*n] = byte(sym)
+		f.emit(p[*n])
+		*n++
+	case sym == 256:
+		f.state = stateHeader
+	case sym < 286:
+		sym -= 257
+		extra, err := f.read(lengthExtra[sym])
+		if err != nil {
+			f.err = err
+			return
+		}
+		length := lengthBase[sym] + extra
+		dsym, err := f.decode(f.dist)
+		if err != nil {
+			f.err = err
+			return
+		}
+		if dsym >= 30 {
+			f.err = f.corrupt()
+			return
+		}
+		extra, err = f.read(distExtra[dsym])
+		if err != nil {
+			f.err = err
+			return
+		}
+		dist := distBase[dsym] + extra
+		if dist > f.have {
+			f.err = f.corrupt()
+			return
+		}
+		f.copyLen, f.copyDist = length, dist
+	default:
+		f.err = f.corrupt()
+	}
+}
+
+// Bits looked up at once when decoding.
+const huffmanFast = 9
+
+// Canonical Huffman code, decoded with a table for codes of up to huffmanFast bits and
+// one bit at a time for longer ones.
+type huffman struct {
+	count  [16]int
+	symbol []int
+	// Symbol and length of the codes of up to huffmanFast bits, by their reversed bits.
+	fast [1 << huffmanFast]struct {
+		sym    uint16
+		length uint8
+	}
+}
+
+// Build the code from the length of the code of each symbol, zero for unused ones.
+// Over subscribed lengths are refused, incomplete ones fail when an unused code is read.
+func newHuffman(lengths []uint8) (*huffman, bool) {
+	h := &huffman{symbol: make([]int, 0, len(lengths))}
+	for _, l := range lengths {
+		h.count[l]++
+	}
+	h.count[0] = 0
+	left := 1
+	for l := 1; l < 16; l++ {
+		left = left<<1 - h.count[l]
+		if left < 0 {
+			return nil, false
+		}
+	}
+
+	var next [16]int
+	code := 0
+	for l := 1; l < 16; l++ {
+		code = (code + h.count[l-1]) << 1
+		next[l] = code
+	}
+	for l := 1; l < 16; l++ {
+		for sym, sl := range lengths {
+			if int(sl) == l {
+				h.symbol = append(h.symbol, sym)
+			}
+		}
+	}
+	for sym, l := range lengths {
+		if l == 0 || l > huffmanFast {
+			continue
+		}
+		code := next[l]
+		next[l]++
+		reversed := 0
+		for i := uint8(0); i < l; i++ {
+			reversed |= (code >> i & 1) << (l - 1 - i)
+		}
+		for i := reversed; i < len(h.fast); i += 1 << l {
+			h.fast[i].sym, h.fast[i].length = uint16(sym), l
+		}
+	}
+	return h, true
+}
+
+func (f *inflater) decode(h *huffman) (int, error) {
+	// Near the end of the stream there may be fewer bits than the table looks up.
+	if f.need(huffmanFast) != nil && f.nbits == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if e := h.fast[f.bits&(1<<huffmanFast-1)]; e.length > 0 && uint(e.length) <= f.nbits {
+		f.consume(uint(e.length))
+		return int(e.sym), nil
+	}
+
+	code, first, index := 0, 0, 0
+	for l := 1; l < 16; l++ {
+		bit, err := f.read(1)
+		if err != nil {
+			return 0, err
+		}
+		code |= bit
+		count := h.count[l]
+		if code-first < count {
+			return h.symbol[index+code-first], nil
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	return 0, f.corrupt()
+}
+
+var (
+	fixedOnce           sync.Once
+	fixedLit, fixedDist *huffman
+)
+
+func fixedHuffman() (*huffman, *huffman) {
+	fixedOnce.Do(func() {
+		var lengths [288]uint8
+		for i := range lengths {
+			switch {
+			case i < 144:
+				lengths[i] = 8
+			case i < 256:
+				lengths[i] = 9
+			case i < 280:
+				lengths[i] = 7
+			default:
+				lengths[i] = 8
+			}
+		}
+		dist := make([]uint8, 30)
+		for i := range dist {
+			dist[i] = 5
+		}
+		fixedLit, _ = newHuffman(lengths[:])
+		fixedDist, _ = newHuffman(dist)
+	})
+	return fixedLit, fixedDist
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
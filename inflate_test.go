@@ -0,0 +1,89 @@
+package zipfs
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// Text like data, compressing to dynamic blocks with long matches.
+func inflateInput(n int) []byte {
+	rnd := rand.New(rand.NewSource(1))
+	words := []string{"zip", "file ", "system", " http", "\n", "deflate ", "seek", "point", "0123456789"}
+	b := make([]byte, 0, n)
+	for len(b) < n {
+		if rnd.Intn(50) == 0 {
+			b = append(b, byte(rnd.Intn(256)))
+		}
+		b = append(b, words[rnd.Intn(len(words))]...)
+	}
+	return b[:n]
+}
+
+func TestInflater(t *testing.T) {
+	inputs := [][]byte{nil, []byte("a"), inflateInput(1 << 20)}
+	random := make([]byte, 100000)
+	rand.New(rand.NewSource(2)).Read(random)
+	inputs = append(inputs, random)
+
+	for _, input := range inputs {
+		for _, level := range []int{flate.NoCompression, flate.BestSpeed, flate.DefaultCompression, flate.HuffmanOnly} {
+			buf := &bytes.Buffer{}
+			w, _ := flate.NewWriter(buf, level)
+			w.Write(input)
+			w.Close()
+			r := bytes.NewReader(buf.Bytes())
+
+			f := newInflater(r, r.Size(), 0, 0, nil)
+			var blocks []struct {
+				offset, out int64
+				history     []byte
+			}
+			f.onBlock = func() {
+				blocks = append(blocks, struct {
+					offset, out int64
+					history     []byte
+				}{f.bitOffset(), f.out, f.history()})
+			}
+			output, err := io.ReadAll(f)
+			if err != nil {
+				t.Fatalf("Level %d: %v", level, err)
+			}
+			if !bytes.Equal(output, input) {
+				t.Fatalf("Level %d: output of %d bytes differs from input of %d bytes", level, len(output), len(input))
+			}
+
+			if len(input) == 1<<20 && level != flate.NoCompression && len(blocks) < 2 {
+				t.Errorf("Level %d: expected several blocks got %d", level, len(blocks))
+			}
+			// Starting at any block gives the rest of the output.
+			for _, b := range blocks {
+				output, err := io.ReadAll(newInflater(r, r.Size(), b.offset, b.out, b.history))
+				if err != nil || !bytes.Equal(output, input[b.out:]) {
+					t.Fatalf("Level %d: block at %d of output %d differs, %v", level, b.offset, b.out, err)
+				}
+			}
+		}
+	}
+}
+
+func TestInflaterCorrupt(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, _ := flate.NewWriter(buf, flate.BestCompression)
+	w.Write(inflateInput(10000))
+	w.Close()
+	b := buf.Bytes()
+
+	// Truncated streams end unexpectedly, reserved block types are corrupt.
+	if _, err := io.ReadAll(newInflater(bytes.NewReader(b[:len(b)/2]), int64(len(b)/2), 0, 0, nil)); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected %v got %v", io.ErrUnexpectedEOF, err)
+	}
+	reserved := []byte{0x07}
+	if _, err := io.ReadAll(newInflater(bytes.NewReader(reserved), 1, 0, 0, nil)); err == nil {
+		t.Error("Expected reserved block type to be corrupt")
+	} else if _, ok := err.(flate.CorruptInputError); !ok {
+		t.Errorf("Expected a flate.CorruptInputError got %v", err)
+	}
+}
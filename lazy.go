@@ -0,0 +1,35 @@
+package zipfs
+
+import (
+	"archive/zip"
+)
+
+// Read the central directory of the zip archive and index it the first time the file
+// system is used, rather than when it is created. Directory listings are built the
+// first time each directory is opened, keeping at most maxDirs of them cached.
+func Lazy(maxDirs int) Option {
+	return func(fs *zipFS) {
+		fs.lazy = true
+		fs.dirs = newLRU(int64(maxDirs))
+	}
+}
+
+// Return the listing of the named directory, building it from the index when it is not
+// in the cache.
+func (fs *zipFS) listing(name string) []*zip.FileHeader {
+	if files, ok := fs.dirs.Get(name); ok {
+		return files.([]*zip.FileHeader)
+	}
+
+	var files []*zip.FileHeader
+	for _, n := range fs.trie.childNodes(name) {
+		switch entry := n.meta.(type) {
+		case *zipEntry:
+			files = append(files, entry.FileHeader)
+		case *zipDir:
+			files = append(files, entry.Info)
+		}
+	}
+	fs.dirs.Add(name, files, 1)
+	return files
+}
@@ -0,0 +1,64 @@
+package zipfs
+
+import (
+	"testing"
+)
+
+func TestZipFS_Lazy(t *testing.T) {
+	fs := InitZipFs("testdata/compressed.zip", Lazy(1)).(*zipFS)
+	if fs.trie.size != 0 {
+		t.Fatal("Expected the central directory to be read on first use")
+	}
+
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{"/", 2},
+		{"/dirA", 3},
+		{"/dirA/dirB", 2},
+		{"/dirA/dirC", 2},
+		{"/dirA", 3},
+	}
+	for _, test := range tests {
+		file, err := fs.Open(test.name)
+		if err != nil {
+			t.Fatalf("Open(%q): %v", test.name, err)
+		}
+		if infos, _ := file.Readdir(-1); len(infos) != test.count {
+			t.Errorf("Open(%q): expected %d entries got %d", test.name, test.count, len(infos))
+		}
+	}
+	if fs.dirs.ll.Len() != 1 {
+		t.Errorf("Expected 1 cached listing got %d", fs.dirs.ll.Len())
+	}
+
+	if _, err := fs.Open("/dirA/dirC/text5.txt"); err != nil {
+		t.Error(err)
+	}
+	if _, err := fs.Open("/dirA/dirC/text7.txt"); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestLRU(t *testing.T) {
+	c := newLRU(10)
+	c.Add("a", 1, 4)
+	c.Add("b", 2, 4)
+	c.Get("a")
+	c.Add("c", 3, 4)
+	c.Add("d", 4, 11)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	if _, ok := c.Get("d"); ok {
+		t.Error("Expected d to be too costly to cache")
+	}
+	if v, ok := c.Get("a"); !ok || v.(int) != 1 {
+		t.Error("Expected a to be cached")
+	}
+	if c.cost != 8 {
+		t.Errorf("Expected cost 8 got %d", c.cost)
+	}
+}
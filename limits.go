@@ -0,0 +1,108 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Limits on the archive, checked against the declared sizes while indexing. A zero
+// value does not limit. Reading a file always fails once it yields more than its
+// declared size.
+type Limits struct {
+	// Number of entries in the archive.
+	Entries uint64
+	// Total uncompressed size of the files.
+	TotalSize uint64
+	// Uncompressed size of each file.
+	FileSize uint64
+	// Ratio of the uncompressed size of each file to its compressed size.
+	Ratio uint64
+	// Number of segments in the path of each entry.
+	Depth int
+}
+
+// Refuse to index archives exceeding the limits.
+func Limit(limits Limits) Option {
+	return func(fs *zipFS) {
+		fs.limits = limits
+	}
+}
+
+// Error returned when indexing an archive that exceeds one of its Limits.
+type LimitError struct {
+	// Entry that exceeds the limit, empty for limits on the whole archive.
+	Name string
+	// Name of the exceeded field of Limits.
+	Limit string
+}
+
+func (e *LimitError) Error() string {
+	if e.Name == "" {
+		return "zipfs: archive exceeds the " + e.Limit + " limit"
+	}
+	return "zipfs: entry " + strconv.Quote(e.Name) + " exceeds the " + e.Limit + " limit"
+}
+
+// Error returned by reads of a file that yields more than its declared size.
+var ErrSizeExceeded = errors.New("zipfs: file exceeds its declared size")
+
+func (l *Limits) checkEntries(n uint64) error {
+	if l.Entries > 0 && n > l.Entries {
+		return &LimitError{Limit: "Entries"}
+	}
+	return nil
+}
+
+// Check the entry against the limits, adding its size to the total.
+func (l *Limits) check(entry *zipEntry, total *uint64) error {
+	if l.Depth > 0 && strings.Count(strings.Trim(entry.Name, "/"), "/")+1 > l.Depth {
+		return &LimitError{Name: entry.Name, Limit: "Depth"}
+	}
+	if entry.Mode().IsDir() {
+		return nil
+	}
+	size := entry.UncompressedSize64
+	if l.FileSize > 0 && size > l.FileSize {
+		return &LimitError{Name: entry.Name, Limit: "FileSize"}
+	}
+	if l.Ratio > 0 && entry.Method != zip.Store && size > entry.CompressedSize64*l.Ratio {
+		return &LimitError{Name: entry.Name, Limit: "Ratio"}
+	}
+	*total += size
+	if l.TotalSize > 0 && (*total > l.TotalSize || *total < size) {
+		return &LimitError{Limit: "TotalSize"}
+	}
+	return nil
+}
+
+// Reads at most the declared size of a file, failing with ErrSizeExceeded rather than
+// ending when there is more.
+type limitedReader struct {
+	io.ReadCloser
+	remaining uint64
+}
+
+func (r *limitedReader) Read(b []byte) (int, error) {
+	if r.remaining == 0 {
+		var one [1]byte
+		for {
+			n, err := r.ReadCloser.Read(one[:])
+			// archive/zip reports reading past the declared size as a format error.
+			if err == zip.ErrFormat || n > 0 && (err == nil || err == io.EOF) {
+				return 0, ErrSizeExceeded
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	if uint64(len(b)) > r.remaining {
+		b = b[:r.remaining]
+	}
+	n, err := r.ReadCloser.Read(b)
+	r.remaining -= uint64(n)
+	return n, err
+}
@@ -0,0 +1,90 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"hash/crc32"
+	"io"
+	"testing"
+)
+
+func TestZipFS_Limits(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, _ := w.Create("a/b/c.txt")
+	f.Write(make([]byte, 1<<20))
+	f, _ = w.Create("d.txt")
+	io.WriteString(f, "hello")
+	w.Close()
+	b := buf.Bytes()
+	z, _ := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+
+	tests := []struct {
+		limits   Limits
+		expected LimitError
+	}{
+		{Limits{Entries: 1}, LimitError{Limit: "Entries"}},
+		{Limits{TotalSize: 1 << 20}, LimitError{Limit: "TotalSize"}},
+		{Limits{FileSize: 1 << 10}, LimitError{Name: "a/b/c.txt", Limit: "FileSize"}},
+		{Limits{Ratio: 100}, LimitError{Name: "a/b/c.txt", Limit: "Ratio"}},
+		{Limits{Depth: 2}, LimitError{Name: "a/b/c.txt", Limit: "Depth"}},
+	}
+	for _, test := range tests {
+		_, err := OpenZipFS(z, nil, Limit(test.limits))
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || *limitErr != test.expected {
+			t.Errorf("Limits %+v: expected %v got %v", test.limits, &test.expected, err)
+		}
+	}
+
+	// The entry count is checked from the end of central directory record.
+	_, err := openZipFS(bytes.NewReader(b), int64(len(b)), "", []Option{Limit(Limits{Entries: 1})})
+	if limitErr, ok := err.(*LimitError); !ok || limitErr.Limit != "Entries" {
+		t.Errorf("Expected entries limit error got %v", err)
+	}
+
+	if _, err := OpenZipFS(z, nil, Limit(Limits{Entries: 2, TotalSize: 2 << 20, FileSize: 1 << 20, Ratio: 2000, Depth: 3})); err != nil {
+		t.Errorf("Expected archive within limits got %v", err)
+	}
+}
+
+func TestZipFS_SizeExceeded(t *testing.T) {
+	content := bytes.Repeat([]byte("zipfs"), 1000)
+	compressed := &bytes.Buffer{}
+	fw, _ := flate.NewWriter(compressed, flate.BestCompression)
+	fw.Write(content)
+	fw.Close()
+
+	// Declare a fraction of the real size, as a zip bomb would.
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, _ := w.CreateRaw(&zip.FileHeader{
+		Name:               "bomb.txt",
+		Method:             zip.Deflate,
+		CRC32:              crc32.ChecksumIEEE(content[:100]),
+		CompressedSize64:   uint64(compressed.Len()),
+		UncompressedSize64: 100,
+	})
+	f.Write(compressed.Bytes())
+	w.Close()
+	r := bytes.NewReader(buf.Bytes())
+
+	for _, opts := range [][]Option{nil, {Lazy(1)}} {
+		z, _ := zip.NewReader(r, r.Size())
+		fs := NewZipFSWithReaderAt(z, r, opts...)
+		file, err := fs.Open("/bomb.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(file)
+		if err != ErrSizeExceeded {
+			t.Errorf("Expected %v got %v", ErrSizeExceeded, err)
+		}
+		if len(b) != 100 {
+			t.Errorf("Expected 100 bytes before the error got %d", len(b))
+		}
+		file.Close()
+	}
+}
@@ -0,0 +1,217 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Directory listing rendered by ListingHandler.
+type Listing struct {
+	Path    string         `json:"path"`
+	Entries []ListingEntry `json:"entries"`
+}
+
+// Entry of a directory listing.
+type ListingEntry struct {
+	Name string `json:"name"`
+	// Path of the entry, with a trailing slash for directories, and its escaped form
+	// relative to the listing for links, which keeps working under http.StripPrefix.
+	Path           string `json:"path"`
+	URL            string `json:"url"`
+	Dir            bool   `json:"dir"`
+	Size           int64  `json:"size"`
+	CompressedSize int64  `json:"compressedSize"`
+	// Compressed size over size, 1 for stored files and 0 for empty ones.
+	Ratio   float64   `json:"ratio"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// ListingOption configures a ListingHandler.
+type ListingOption func(h *listingHandler)
+
+// Render listings with t, executed with a Listing, instead of the default template.
+func ListingTemplate(t *template.Template) ListingOption {
+	return func(h *listingHandler) {
+		h.template = t
+	}
+}
+
+// Respond to requests for directories without an index.html with not found, rather than
+// listing them.
+func DisableListings() ListingOption {
+	return func(h *listingHandler) {
+		h.disabled = true
+	}
+}
+
+var defaultListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{.Path}}</title></head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Compressed</th><th>Ratio</th><th>Modified</th></tr>
+{{range .Entries}}<tr><td><a href="{{.URL}}">{{.Name}}{{if .Dir}}/{{end}}</a></td>{{if .Dir}}<td></td><td></td><td></td>{{else}}<td>{{.Size}}</td><td>{{.CompressedSize}}</td><td>{{printf "%.2f" .Ratio}}</td>{{end}}<td>{{.ModTime.Format "2006-01-02 15:04:05"}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type listingHandler struct {
+	fileSystem http.FileSystem
+	h          http.Handler
+	template   *template.Template
+	disabled   bool
+}
+
+// Render the listings of directories without an index.html, as HTML or as JSON for
+// requests accepting application/json, serving every other request with h. The roots of
+// nested zip files are listed, or their index.html served, rather than left to h.
+func ListingHandler(fileSystem http.FileSystem, h http.Handler, opts ...ListingOption) http.Handler {
+	l := &listingHandler{fileSystem: fileSystem, h: h, template: defaultListingTemplate}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+func (l *listingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Leave redirecting to the trailing slash, and serving index.html, to h.
+	name := path.Clean("/" + r.URL.Path)
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		l.h.ServeHTTP(w, r)
+		return
+	}
+	// Opened with the trailing slash, which opens the root of nested zip files, see Nested.
+	dir := name
+	if dir != "/" {
+		dir += "/"
+	}
+	f, err := l.fileSystem.Open(dir)
+	if err != nil {
+		l.h.ServeHTTP(w, r)
+		return
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err != nil || !fi.IsDir() {
+		l.h.ServeHTTP(w, r)
+		return
+	}
+	if l.hasIndex(name) {
+		// h would redirect the root of a nested zip file to the zip file itself.
+		if l.isFile(name) {
+			l.serveIndex(w, r, name)
+			return
+		}
+		l.h.ServeHTTP(w, r)
+		return
+	}
+	if l.disabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	listing, err := newListing(name, f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(listing)
+		return
+	}
+	// Rendered first, so a failing template responds with an error instead of half a page.
+	buf := &bytes.Buffer{}
+	if err := l.template.Execute(buf, listing); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
+
+func (l *listingHandler) hasIndex(dir string) bool {
+	f, err := l.fileSystem.Open(path.Join(dir, "index.html"))
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+func (l *listingHandler) isFile(name string) bool {
+	f, err := l.fileSystem.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	return err == nil && !fi.IsDir()
+}
+
+func (l *listingHandler) serveIndex(w http.ResponseWriter, r *http.Request, dir string) {
+	f, err := l.fileSystem.Open(path.Join(dir, "index.html"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Not http.ServeContent, compressed files cannot seek to find their size.
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.FormatInt(fi.Size(), 10))
+	if !fi.ModTime().IsZero() {
+		w.Header().Set("Last-Modified", fi.ModTime().UTC().Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodHead {
+		io.Copy(w, f)
+	}
+}
+
+func newListing(name string, dir http.File) (*Listing, error) {
+	infos, err := dir.Readdir(-1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+
+	listing := &Listing{Path: strings.TrimRight(name, "/") + "/", Entries: []ListingEntry{}}
+	for _, fi := range infos {
+		entry := ListingEntry{
+			Name:           fi.Name(),
+			Path:           listing.Path + fi.Name(),
+			Dir:            fi.IsDir(),
+			Size:           fi.Size(),
+			CompressedSize: fi.Size(),
+			ModTime:        fi.ModTime(),
+		}
+		if fh, ok := fi.Sys().(*zip.FileHeader); ok {
+			entry.CompressedSize = int64(fh.CompressedSize64)
+		}
+		entry.URL = (&url.URL{Path: fi.Name()}).String()
+		if entry.Dir {
+			entry.Path += "/"
+			entry.URL += "/"
+			entry.Size, entry.CompressedSize = 0, 0
+		} else if entry.Size > 0 {
+			entry.Ratio = float64(entry.CompressedSize) / float64(entry.Size)
+		}
+		listing.Entries = append(listing.Entries, entry)
+	}
+	return listing, nil
+}
@@ -0,0 +1,107 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func listingZip() http.FileSystem {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for name, content := range map[string]string{
+		"docs/guide.html":   strings.Repeat("guide ", 100),
+		"docs/logo.png":     "PNG",
+		"docs/a b?.txt":     "",
+		"docs/api/ref.html": "ref",
+		"site/index.html":   "<h1>Site</h1>",
+	} {
+		f, _ := w.Create(name)
+		f.Write([]byte(content))
+	}
+	w.Close()
+	z, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	return NewZipFS(z)
+}
+
+func TestListingHandler(t *testing.T) {
+	fs := listingZip()
+	h := ListingHandler(fs, http.FileServer(fs))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/docs/", nil)
+	req.Header.Set("Accept", "application/json")
+	h.ServeHTTP(rec, req)
+	var listing Listing
+	if err := json.Unmarshal(rec.Body.Bytes(), &listing); err != nil {
+		t.Fatal(err)
+	}
+	if listing.Path != "/docs/" || len(listing.Entries) != 4 {
+		t.Fatalf("Expected 4 entries in /docs/ got %+v", listing)
+	}
+	var names []string
+	for _, entry := range listing.Entries {
+		names = append(names, entry.Name)
+	}
+	if expected := "a b?.txt api guide.html logo.png"; strings.Join(names, " ") != expected {
+		t.Errorf("Expected %s got %s", expected, strings.Join(names, " "))
+	}
+	api, guide := listing.Entries[1], listing.Entries[2]
+	if !api.Dir || api.Path != "/docs/api/" {
+		t.Errorf("Expected api directory got %+v", api)
+	}
+	if guide.Size != 600 || guide.CompressedSize >= 600 || guide.Ratio <= 0 || guide.Ratio >= 1 {
+		t.Errorf("Expected guide.html compressed got %+v", guide)
+	}
+	if escaped := listing.Entries[0].URL; escaped != "a%20b%3F.txt" {
+		t.Errorf("Expected escaped URL got %s", escaped)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/docs/", nil))
+	body := rec.Body.String()
+	if rec.Header().Get("Content-Type") != "text/html; charset=utf-8" || !strings.Contains(body, `<a href="api/">api/</a>`) {
+		t.Errorf("Expected HTML listing got %s", body)
+	}
+
+	// Directories with an index.html, files and redirects are served by h.
+	for target, code := range map[string]int{"/site/": 200, "/docs/logo.png": 200, "/docs": 301} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != code || code == 200 && strings.Contains(rec.Body.String(), "Index of") {
+			t.Errorf("%s: expected %d got %d %s", target, code, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestListingHandler_Options(t *testing.T) {
+	fs := listingZip()
+	tmpl := template.Must(template.New("").Parse(`{{.Path}}{{range .Entries}} {{.Name}}{{end}}`))
+
+	rec := httptest.NewRecorder()
+	ListingHandler(fs, http.FileServer(fs), ListingTemplate(tmpl)).ServeHTTP(rec, httptest.NewRequest("GET", "/docs/api/", nil))
+	if rec.Body.String() != "/docs/api/ ref.html" {
+		t.Errorf("Expected the template rendered got %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	broken := template.Must(template.New("").Parse(`{{.Missing}}`))
+	ListingHandler(fs, http.FileServer(fs), ListingTemplate(broken)).ServeHTTP(rec, httptest.NewRequest("GET", "/docs/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected %d from a failing template got %d", http.StatusInternalServerError, rec.Code)
+	}
+
+	h := ListingHandler(fs, http.FileServer(fs), DisableListings())
+	for target, code := range map[string]int{"/docs/": 404, "/": 404, "/site/": 200, "/docs/logo.png": 200} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != code {
+			t.Errorf("%s: expected %d got %d", target, code, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package zipfs
+
+import (
+	"container/list"
+	"sync"
+)
+
+// A least recently used cache, evicting values once their total cost exceeds the
+// capacity. Values costing more than the capacity are not cached.
+type lru struct {
+	mu       sync.Mutex
+	capacity int64
+	cost     int64
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	value interface{}
+	cost  int64
+}
+
+func newLRU(capacity int64) *lru {
+	return &lru{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+func (c *lru) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*lruItem).value, true
+}
+
+func (c *lru) Add(key string, value interface{}, cost int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+	if cost > c.capacity {
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruItem{key: key, value: value, cost: cost})
+	c.cost += cost
+	for c.cost > c.capacity {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *lru) remove(e *list.Element) {
+	item := c.ll.Remove(e).(*lruItem)
+	delete(c.items, item.key)
+	c.cost -= item.cost
+}
+
+// Number of values in the cache and their total cost.
+func (c *lru) stats() (int, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len(), c.cost
+}
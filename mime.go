@@ -0,0 +1,141 @@
+package zipfs
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Path of the manifest of content types in the archive. It is a JSON object mapping
+// extensions, such as ".wasm", and paths, such as "/data/feed", to content types.
+const MIMEManifest = "/.zipfs/mime.json"
+
+// Map extensions, such as ".wasm", to content types, for the files whose type is not in
+// the manifest of the archive.
+func ContentTypes(types map[string]string) Option {
+	return func(fs *zipFS) {
+		if fs.types == nil {
+			fs.types = map[string]string{}
+		}
+		for ext, typ := range types {
+			fs.types[strings.ToLower(ext)] = typ
+		}
+	}
+}
+
+// ContentTyper is implemented by file systems that know the content type of their files.
+type ContentTyper interface {
+	// ContentType returns the content type of the named file, or an empty string when
+	// it is unknown.
+	ContentType(name string) string
+}
+
+func (fs *zipFS) ContentType(name string) string {
+	if fs.ready() != nil {
+		return ""
+	}
+	name = path.Clean("/" + name)
+	if canonical, ok := fs.canonical(name); ok {
+		name = canonical
+	}
+	if typ, ok := fs.pathTypes[name]; ok {
+		return typ
+	}
+	ext := strings.ToLower(path.Ext(name))
+	if typ, ok := fs.types[ext]; ok {
+		return typ
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// Load the content types from the manifest in the archive, when there is one.
+func (fs *zipFS) loadMIME() error {
+	b, ok, err := fs.readIndexed(MIMEManifest)
+	if !ok || err != nil {
+		return err
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return &EntryError{Name: MIMEManifest, Err: err}
+	}
+
+	types := map[string]string{}
+	for ext, typ := range fs.types {
+		types[ext] = typ
+	}
+	fs.types, fs.pathTypes = types, map[string]string{}
+	for key, typ := range manifest {
+		if strings.HasPrefix(key, "/") {
+			name := path.Clean(key)
+			if canonical, ok := fs.canonical(name); ok {
+				name = canonical
+			}
+			fs.pathTypes[name] = typ
+		} else {
+			fs.types[strings.ToLower(key)] = typ
+		}
+	}
+	return nil
+}
+
+// Read the content of the named file while indexing, the found result is false when
+// there is no such file.
+func (fs *zipFS) readIndexed(name string) ([]byte, bool, error) {
+	node, ok := fs.trie.Find(name)
+	if !ok {
+		return nil, false, nil
+	}
+	entry, ok := node.meta.(*zipEntry)
+	if !ok {
+		return nil, false, nil
+	}
+	rc, err := fs.openEntry(entry)
+	if err != nil {
+		return nil, true, &EntryError{Name: name, Err: err}
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(&limitedReader{ReadCloser: rc, remaining: entry.UncompressedSize64})
+	if err != nil {
+		return nil, true, &EntryError{Name: name, Err: err}
+	}
+	return b, true, nil
+}
+
+// Set the Content-Type header of files from the content type known to the file system,
+// or from their extension, or else by sniffing their content, before serving them with h.
+// Unlike http.FileServer, it does not need the file to seek for sniffing.
+func ContentTypeHandler(fileSystem http.FileSystem, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if w.Header().Get("Content-Type") == "" {
+			if typ := contentType(fileSystem, path.Clean("/"+r.URL.Path)); typ != "" {
+				w.Header().Set("Content-Type", typ)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func contentType(fileSystem http.FileSystem, name string) string {
+	if typer, ok := fileSystem.(ContentTyper); ok {
+		if typ := typer.ContentType(name); typ != "" {
+			return typ
+		}
+	} else if typ := mime.TypeByExtension(path.Ext(name)); typ != "" {
+		return typ
+	}
+
+	f, err := fileSystem.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err != nil || fi.IsDir() {
+		return ""
+	}
+	var buf [512]byte
+	n, _ := io.ReadFull(f, buf[:])
+	return http.DetectContentType(buf[:n])
+}
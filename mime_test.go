@@ -0,0 +1,92 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContentTypeHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for name, content := range map[string]string{
+		".zipfs/mime.json": `{".wasm": "application/wasm", "/data/feed": "application/atom+xml"}`,
+		"app.wasm":         "\x00asm",
+		"data/feed":        "<feed/>",
+		"model.glb":        "glTF",
+		"page":             "<!DOCTYPE html><html></html>",
+		"style.css":        "body {}",
+	} {
+		f, _ := w.Create(name)
+		f.Write([]byte(content))
+	}
+	w.Close()
+	z, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	fs := NewZipFS(z, ContentTypes(map[string]string{".GLB": "model/gltf-binary", ".wasm": "text/plain"}))
+
+	tests := map[string]string{
+		"/app.wasm":  "application/wasm",
+		"/data/feed": "application/atom+xml",
+		"/model.glb": "model/gltf-binary",
+		"/page":      "text/html; charset=utf-8",
+		"/style.css": "text/css; charset=utf-8",
+	}
+	h := ContentTypeHandler(fs, http.FileServer(fs))
+	for name, expected := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", name, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200 got %d", name, rec.Code)
+		}
+		if actual := rec.Header().Get("Content-Type"); actual != expected {
+			t.Errorf("%s: expected %s got %s", name, expected, actual)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	ContentTypeHandler(fs, http.FileServer(fs)).ServeHTTP(rec, httptest.NewRequest("GET", "/data/", nil))
+	if actual := rec.Header().Get("Content-Type"); actual != "text/html; charset=utf-8" {
+		t.Errorf("Expected the listing type got %s", actual)
+	}
+
+	buf.Reset()
+	w = zip.NewWriter(buf)
+	f, _ := w.Create(".zipfs/mime.json")
+	f.Write([]byte("{"))
+	w.Close()
+	z, _ = zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if _, err := OpenZipFS(z, nil); err == nil || err.(*EntryError).Name != MIMEManifest {
+		t.Errorf("Expected an error for an invalid manifest got %v", err)
+	}
+}
+
+// Paths in the manifest and looked up resolve to the same file as Open does.
+func TestZipFS_ContentTypeCanonical(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for name, content := range map[string]string{
+		".zipfs/mime.json": `{"/data/feed": "application/atom+xml", "/café/feed": "application/rss+xml"}`,
+		"Data/Feed":        "<feed/>",
+		"café/feed":       "<rss/>",
+	} {
+		f, _ := w.Create(name)
+		f.Write([]byte(content))
+	}
+	w.Close()
+	z, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	fs := NewZipFS(z, CaseInsensitive(), NormalizeNames()).(ContentTyper)
+
+	tests := map[string]string{
+		"/DATA/FEED":  "application/atom+xml",
+		"/Data/Feed":  "application/atom+xml",
+		"/café/feed":  "application/rss+xml",
+		"/café/FEED": "application/rss+xml",
+	}
+	for name, expected := range tests {
+		if actual := fs.ContentType(name); actual != expected {
+			t.Errorf("%q: expected %s got %s", name, expected, actual)
+		}
+	}
+}
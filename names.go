@@ -0,0 +1,103 @@
+package zipfs
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// What to do with entries whose names are unsafe: absolute, containing "." or ".."
+// segments, empty segments, backslashes or NUL bytes, or starting with a drive letter.
+type NamePolicy int
+
+const (
+	// Leave entries with unsafe names out of the index, the default.
+	SkipUnsafeNames NamePolicy = iota
+	// Fail to index the archive.
+	RejectUnsafeNames
+	// Index entries with unsafe names under their cleaned name, resolving ".." segments
+	// within the archive.
+	SanitizeUnsafeNames
+)
+
+// Set the policy for entries with unsafe names, which are reported unless rejected.
+func UnsafeNames(policy NamePolicy) Option {
+	return func(fs *zipFS) {
+		fs.namePolicy = policy
+	}
+}
+
+// Error returned when indexing an archive with an unsafe entry name that is rejected.
+type UnsafeNameError struct {
+	Name string
+}
+
+func (e *UnsafeNameError) Error() string {
+	return "zipfs: unsafe entry name " + strconv.Quote(e.Name)
+}
+
+// Return the entry with its name decoded, normalised and checked as configured, or nil
+// when the entry is skipped.
+func (fs *zipFS) rename(entry *zipEntry) (*zipEntry, error) {
+	name := entry.Name
+	if fs.decodeLegacy && entry.Flags&0x800 == 0 && !utf8.ValidString(name) {
+		name = decodeCP437(name)
+	}
+	if fs.normalize {
+		name = nfc(name)
+	}
+	if unsafeName(name) {
+		switch fs.namePolicy {
+		case RejectUnsafeNames:
+			return nil, &UnsafeNameError{Name: entry.Name}
+		case SanitizeUnsafeNames:
+			name = sanitizeName(name)
+		default:
+			name = ""
+		}
+		fs.report.Unsafe = append(fs.report.Unsafe, entry.Name)
+		if name == "" {
+			return nil, nil
+		}
+	}
+	if name == entry.Name {
+		return entry, nil
+	}
+
+	h := *entry.FileHeader
+	h.Name = name
+	return &zipEntry{FileHeader: &h, file: entry.file, offset: entry.offset}, nil
+}
+
+func unsafeName(name string) bool {
+	if strings.ContainsAny(name, "\\\x00") || strings.HasPrefix(name, "/") || hasDriveLetter(name) {
+		return true
+	}
+	name = strings.TrimSuffix(name, "/")
+	return name == "" || name == "." || name == ".." || strings.HasPrefix(name, "../") || path.Clean(name) != name
+}
+
+// Return the name with backslashes as slashes, without NUL bytes or a drive letter, and
+// cleaned within the root. The name is empty when nothing is left.
+func sanitizeName(name string) string {
+	name = strings.Replace(name, "\\", "/", -1)
+	name = strings.Replace(name, "\x00", "", -1)
+	if hasDriveLetter(name) {
+		name = name[2:]
+	}
+	dir := strings.HasSuffix(name, "/")
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if dir && name != "" {
+		name += "/"
+	}
+	return name
+}
+
+// Whether the name starts with a drive such as "C:" or "C:/", but not a name such as "a:b.txt".
+func hasDriveLetter(name string) bool {
+	if len(name) < 2 || name[1] != ':' || !('a' <= name[0] && name[0] <= 'z' || 'A' <= name[0] && name[0] <= 'Z') {
+		return false
+	}
+	return len(name) == 2 || name[2] == '/' || name[2] == '\\'
+}
@@ -0,0 +1,69 @@
+package zipfs
+
+import (
+	"reflect"
+	"testing"
+)
+
+var craftedNames = []string{
+	"../etc/passwd",
+	"a/../../b.txt",
+	"/abs.txt",
+	"back\\slash.txt",
+	"nul\x00.txt",
+	"C:/win.txt",
+	"./dot.txt",
+	"a//b.txt",
+	"safe/file.txt",
+	"a:b.txt",
+}
+
+func TestZipFS_UnsafeNames(t *testing.T) {
+	z := zipOf(craftedNames...)
+
+	tests := []struct {
+		policy   NamePolicy
+		expected []string
+	}{
+		{SkipUnsafeNames, []string{"/a:b.txt", "/safe", "/safe/file.txt"}},
+		{SanitizeUnsafeNames, []string{
+			"/a", "/a/b.txt", "/a:b.txt", "/abs.txt", "/b.txt", "/back", "/back/slash.txt", "/dot.txt",
+			"/etc", "/etc/passwd", "/nul.txt", "/safe", "/safe/file.txt", "/win.txt",
+		}},
+	}
+	for _, test := range tests {
+		fs := NewZipFS(z, UnsafeNames(test.policy)).(*zipFS)
+		keys := fs.trie.Keys()
+		if keys[0] != "/" || !reflect.DeepEqual(keys[1:], test.expected) {
+			t.Errorf("Policy %d: expected %v got %v", test.policy, test.expected, keys[1:])
+		}
+		if unsafe := fs.Report().Unsafe; !reflect.DeepEqual(unsafe, craftedNames[:8]) {
+			t.Errorf("Policy %d: expected unsafe names %q got %q", test.policy, craftedNames[:8], unsafe)
+		}
+		// Only a letter and colon before a slash, or alone, is a drive.
+		if matches, _ := fs.Glob("*.txt"); len(matches) == 0 || matches[0] != "/a:b.txt" {
+			t.Errorf("Policy %d: expected /a:b.txt globbed got %v", test.policy, matches)
+		}
+		if _, err := fs.Open("/a:b.txt"); err != nil {
+			t.Errorf("Policy %d: expected /a:b.txt to open got %v", test.policy, err)
+		}
+	}
+
+	_, err := OpenZipFS(z, nil, UnsafeNames(RejectUnsafeNames))
+	if e, ok := err.(*UnsafeNameError); !ok || e.Name != "../etc/passwd" {
+		t.Errorf("Expected UnsafeNameError got %v", err)
+	}
+	fs := NewZipFS(z, UnsafeNames(RejectUnsafeNames))
+	if _, err := fs.Open("/safe/file.txt"); err == nil {
+		t.Error("Expected Open to fail on a rejected archive")
+	}
+}
+
+func TestZipFS_OpenCleansName(t *testing.T) {
+	fs := InitZipFs("testdata/uncompressed.zip")
+	for _, name := range []string{"/dirA/./dirB/text3.txt", "/dirA/dirC/../dirB/text3.txt", "/../dirA/dirB/text3.txt", "//dirA/dirB//text3.txt", "/dirA/"} {
+		if _, err := fs.Open(name); err != nil {
+			t.Errorf("Open(%q): %v", name, err)
+		}
+	}
+}
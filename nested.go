@@ -0,0 +1,102 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Traverse the zip files in the archive as directories, such that /docs/v1.zip/index.html
+// opens index.html in /docs/v1.zip, keeping at most maxArchives of them open. Opening
+// /docs/v1.zip/ opens its root, while /docs/v1.zip is still the zip file itself, so
+// http.FileServer redirects requests for the root to the zip file, ListingHandler serves
+// them instead. Stored zip files are read in place, compressed ones are read whole when
+// first opened, see Spill. The zip files in a nested zip file are not traversed.
+func Nested(maxArchives int) Option {
+	return func(fs *zipFS) {
+		fs.archives = newLRU(int64(maxArchives))
+	}
+}
+
+// Open the named path within a zip file in the archive. The found result is false when
+// no zip file in the archive leads to the path.
+func (fs *zipFS) openNested(name string) (f http.File, found bool, err error) {
+	// Segments are matched in name itself, lowercasing can change the length of a path.
+	for i := 1; i <= len(name); i++ {
+		if i < len(name) && name[i] != '/' {
+			continue
+		}
+		if i < 4 || !strings.EqualFold(name[i-4:i], ".zip") {
+			continue
+		}
+		if archive, ok := fs.canonical(name[:i]); ok {
+			node, _ := fs.trie.Find(archive)
+			if entry, ok := node.meta.(*zipEntry); ok {
+				inner, err := fs.nestedFS(archive, entry)
+				if err != nil {
+					return nil, true, err
+				}
+				f, err := inner.Open("/" + strings.TrimLeft(name[i:], "/"))
+				return f, true, err
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+// Return the file system of the named zip file in the archive, opening it when it is not
+// in the cache.
+func (fs *zipFS) nestedFS(name string, entry *zipEntry) (*zipFS, error) {
+	if cached, ok := fs.archives.Get(name); ok {
+		if err, ok := cached.(error); ok {
+			return nil, err
+		}
+		return cached.(*zipFS), nil
+	}
+	inner, err := fs.openNestedFS(entry)
+	if err != nil {
+		// Remember the zip files that cannot be opened, rather than reading them again.
+		fs.archives.Add(name, err, 1)
+		return nil, err
+	}
+	fs.archives.Add(name, inner, 1)
+	return inner, nil
+}
+
+// Open the zip file in the archive, with the settings of the archive.
+func (fs *zipFS) openNestedFS(entry *zipEntry) (*zipFS, error) {
+	size := int64(entry.UncompressedSize64)
+	var r io.ReaderAt
+	if fs.readerAt != nil && entry.Method == zip.Store {
+		offset, err := entry.dataOffset()
+		if err != nil {
+			return nil, err
+		}
+		r = io.NewSectionReader(fs.readerAt, offset, size)
+	} else {
+		f, err := fs.processZipFile(entry)
+		if err != nil {
+			return nil, err
+		}
+		r, _, err = fs.spill(f, size)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	inner := newZipFS(r, nil)
+	inner.normalize, inner.decodeLegacy = fs.normalize, fs.decodeLegacy
+	inner.namePolicy, inner.duplicatePolicy = fs.namePolicy, fs.duplicatePolicy
+	inner.limits, inner.verifyCRC = fs.limits, fs.verifyCRC
+	inner.password, inner.decompressors = fs.password, fs.decompressors
+	inner.spillMemory, inner.spillSize = fs.spillMemory, fs.spillSize
+	if fs.folded != nil {
+		inner.folded = map[string]string{}
+	}
+	if err := inner.openArchive(size, ""); err != nil {
+		return nil, err
+	}
+	return inner, nil
+}
@@ -0,0 +1,132 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestZipFS_Nested(t *testing.T) {
+	for _, method := range []uint16{zip.Store, zip.Deflate} {
+		r := nestedZip(method)
+		z, _ := zip.NewReader(r, r.Size())
+		fs := NewZipFSWithReaderAt(z, r, Nested(1))
+
+		if content := readAll(t, fs, "/inner.zip/hello.txt"); string(content) != "hello" {
+			t.Errorf("Method %d: expected hello got %q", method, content)
+		}
+		if fi, err := Must(fs.Open("/inner.zip")).Stat(); err != nil || fi.IsDir() {
+			t.Errorf("Method %d: expected /inner.zip to be the zip file got %v %v", method, fi, err)
+		}
+		dir, err := fs.Open("/inner.zip/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if infos, _ := dir.Readdir(-1); len(infos) != 1 || infos[0].Name() != "hello.txt" {
+			t.Errorf("Method %d: expected hello.txt listed got %v", method, infos)
+		}
+		if _, err := fs.Open("/inner.zip/missing.txt"); !os.IsNotExist(err) {
+			t.Errorf("Method %d: expected not exist got %v", method, err)
+		}
+
+		if _, err := NewZipFSWithReaderAt(z, r).Open("/inner.zip/hello.txt"); !os.IsNotExist(err) {
+			t.Errorf("Method %d: expected not exist without Nested got %v", method, err)
+		}
+	}
+
+	// Zip files that are not zip archives are served as they are.
+	fs := NewZipFS(zipOf("fake.zip"), Nested(1))
+	if content := readAll(t, fs, "/fake.zip"); string(content) != "fake.zip" {
+		t.Errorf("Expected fake.zip got %q", content)
+	}
+
+	r := nestedZip(zip.Deflate)
+	z, _ := zip.NewReader(r, r.Size())
+	server := http.FileServer(NewZipFS(z, Nested(1)))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("GET", "/inner.zip", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "PK") {
+		t.Errorf("Expected the zip file served got %d %q", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("GET", "/inner.zip/hello.txt", nil))
+	if rec.Body.String() != "hello" {
+		t.Errorf("Expected hello got %q", rec.Body.String())
+	}
+}
+
+// Ⱥ is longer in UTF-8 lowercased, which once threw the positions of ".zip" off.
+func TestZipFS_NestedLongerLowercase(t *testing.T) {
+	inner := &bytes.Buffer{}
+	w := zip.NewWriter(inner)
+	f, _ := w.Create("hello.txt")
+	f.Write([]byte("hello"))
+	w.Close()
+	outer := &bytes.Buffer{}
+	w = zip.NewWriter(outer)
+	f, _ = w.Create("ȺȺȺ/ȺȺȺ.zip")
+	f.Write(inner.Bytes())
+	w.Close()
+	z, _ := zip.NewReader(bytes.NewReader(outer.Bytes()), int64(outer.Len()))
+	fs := NewZipFS(z, Nested(1))
+
+	if _, err := fs.Open("/ȺȺȺȺȺȺ.zip"); !os.IsNotExist(err) {
+		t.Errorf("Expected not exist got %v", err)
+	}
+	if _, err := fs.Open("/ȺȺȺȺȺȺ.zip/ȺȺȺ.zip/a"); !os.IsNotExist(err) {
+		t.Errorf("Expected not exist got %v", err)
+	}
+	if content := readAll(t, fs, "/ȺȺȺ/ȺȺȺ.zip/hello.txt"); string(content) != "hello" {
+		t.Errorf("Expected hello got %q", content)
+	}
+}
+
+func TestListingHandler_Nested(t *testing.T) {
+	inner := &bytes.Buffer{}
+	w := zip.NewWriter(inner)
+	f, _ := w.Create("index.html")
+	f.Write([]byte("<h1>v2</h1>"))
+	w.Close()
+	r := nestedZip(zip.Store)
+	outer := &bytes.Buffer{}
+	w = zip.NewWriter(outer)
+	z, _ := zip.NewReader(r, r.Size())
+	for _, file := range z.File {
+		w.Copy(file)
+	}
+	f, _ = w.CreateHeader(&zip.FileHeader{Name: "v2.zip", Method: zip.Store})
+	f.Write(inner.Bytes())
+	w.Close()
+	z, _ = zip.NewReader(bytes.NewReader(outer.Bytes()), int64(outer.Len()))
+	fs := NewZipFS(z, Nested(2))
+	h := ListingHandler(fs, http.FileServer(fs))
+
+	tests := []struct {
+		url  string
+		code int
+		body string
+	}{
+		{"/inner.zip/", http.StatusOK, `<a href="hello.txt">`},
+		{"/v2.zip/", http.StatusOK, "<h1>v2</h1>"},
+		{"/inner.zip", http.StatusOK, "PK"},
+		{"/inner.zip/hello.txt", http.StatusOK, "hello"},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", test.url, nil))
+		if rec.Code != test.code || !strings.Contains(rec.Body.String(), test.body) {
+			t.Errorf("%s: expected %d %q got %d %q", test.url, test.code, test.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	// http.FileServer on its own redirects the root to the zip file.
+	rec := httptest.NewRecorder()
+	http.FileServer(fs).ServeHTTP(rec, httptest.NewRequest("GET", "/inner.zip/", nil))
+	if rec.Code != http.StatusMovedPermanently {
+		t.Errorf("Expected %d got %d", http.StatusMovedPermanently, rec.Code)
+	}
+}
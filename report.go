@@ -0,0 +1,22 @@
+package zipfs
+
+// Problems found while indexing the archive.
+type Report struct {
+	// Paths that are equal under case folding, by case insensitive file systems. Each
+	// group is sorted and its first path is the one opened.
+	Collisions [][]string
+	// Unsafe entry names, as stored in the archive, that were skipped or sanitised.
+	Unsafe []string
+	// Paths shared by more than one entry.
+	Duplicates []string
+}
+
+// Reporter is implemented by file systems that report the problems found while indexing.
+type Reporter interface {
+	Report() Report
+}
+
+func (fs *zipFS) Report() Report {
+	fs.ready()
+	return fs.report
+}
@@ -0,0 +1,228 @@
+package zipfs
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Match is a file found by Search.
+type Match struct {
+	// Path of the file, starting with "/".
+	Path string `json:"path"`
+	// Score of the match, higher is better.
+	Score int `json:"score"`
+	// Positions holds the rune index in Path of each matched query rune.
+	Positions []int `json:"positions"`
+}
+
+// Searcher is implemented by file systems that can fuzzy search their file paths.
+type Searcher interface {
+	// Search returns the files whose path contains the runes of query in order,
+	// ignoring case, best match first. If limit is positive, at most limit
+	// matches are returned.
+	Search(query string, limit int) []Match
+}
+
+const (
+	scoreMatch        = 16
+	scoreSegmentStart = 10
+	scoreWordStart    = 8
+	scoreCamelCase    = 7
+	scoreConsecutive  = 8
+	scoreBasename     = 4
+	penaltyGapStart   = 3
+	penaltyGapExtend  = 1
+)
+
+func (fs *zipFS) Search(query string, limit int) []Match {
+	if fs.normalize {
+		query = nfc(query)
+	}
+	q := []rune(query)
+	if len(q) == 0 || fs.ready() != nil {
+		return nil
+	}
+
+	var matches []Match
+	for _, n := range fs.trie.fuzzyNodes(query) {
+		if _, ok := n.meta.(*zipEntry); !ok {
+			continue
+		}
+		score, positions := scorePath(n.key, q)
+		matches = append(matches, Match{Path: n.key, Score: score, Positions: positions})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Path) != len(b.Path) {
+			return len(a.Path) < len(b.Path)
+		}
+		return a.Path < b.Path
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Returns the best scoring alignment of query as a subsequence of name, the
+// caller must ensure that query is a subsequence of name.
+//
+// Matches are rewarded for starting a path segment or a word within it, for
+// following the previous match and for falling in the base name, while gaps
+// between matches are penalised.
+func scorePath(name string, query []rune) (int, []int) {
+	const none = -1 << 30
+
+	runes := []rune(name)
+	base := strings.LastIndexByte(name, '/')
+	base = len([]rune(name[:base+1]))
+
+	// score[i][j] is the best score of query[:i+1] with query[i] matched at runes[j].
+	n, m := len(runes), len(query)
+	score := make([][]int, m)
+	for i := range score {
+		score[i] = make([]int, n)
+		for j := range score[i] {
+			score[i][j] = none
+		}
+	}
+
+	for i, qr := range query {
+		qr = unicode.ToLower(qr)
+		gap := none
+		for j := i; j < n; j++ {
+			if i > 0 && j >= 2 {
+				gap = maxInt(gap-penaltyGapExtend, score[i-1][j-2]-penaltyGapStart)
+				if gap < none {
+					gap = none
+				}
+			}
+			if unicode.ToLower(runes[j]) != qr {
+				continue
+			}
+
+			s := scoreMatch + bonus(runes, j)
+			if j >= base {
+				s += scoreBasename
+			}
+			if i == 0 {
+				score[i][j] = s
+				continue
+			}
+			prev := gap
+			if score[i-1][j-1] != none {
+				prev = maxInt(prev, score[i-1][j-1]+scoreConsecutive)
+			}
+			if prev != none {
+				score[i][j] = prev + s
+			}
+		}
+	}
+
+	best := 0
+	for j := 1; j < n; j++ {
+		if score[m-1][j] > score[m-1][best] {
+			best = j
+		}
+	}
+
+	// Walk back through the rows to recover the matched positions.
+	positions := make([]int, m)
+	positions[m-1] = best
+	for i := m - 1; i > 0; i-- {
+		j := positions[i]
+		want := score[i][j] - scoreMatch - bonus(runes, j)
+		if j >= base {
+			want -= scoreBasename
+		}
+		if score[i-1][j-1] != none && score[i-1][j-1]+scoreConsecutive == want {
+			positions[i-1] = j - 1
+			continue
+		}
+		for k := j - 2; k >= 0; k-- {
+			if score[i-1][k] != none && score[i-1][k]-penaltyGapStart-(j-2-k)*penaltyGapExtend == want {
+				positions[i-1] = k
+				break
+			}
+		}
+	}
+
+	return score[m-1][best], positions
+}
+
+// Returns the bonus for a match at runes[j] based on the rune before it.
+func bonus(runes []rune, j int) int {
+	if j == 0 {
+		return scoreSegmentStart
+	}
+	prev, cur := runes[j-1], runes[j]
+	switch {
+	case prev == '/':
+		return scoreSegmentStart
+	case prev == '_' || prev == '-' || prev == '.' || prev == ' ':
+		return scoreWordStart
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return scoreCamelCase
+	}
+	return 0
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// Scoring every path takes time in proportion to the length of the query.
+	maxSearchQuery = 256
+)
+
+// Serve fuzzy search results for the file system as JSON. The query is read from the
+// "q" parameter and the maximum number of results from "limit", which defaults to 20
+// when missing or 0 and is capped at 100. The file system must implement Searcher.
+func SearchHandler(fileSystem http.FileSystem) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searcher, ok := fileSystem.(Searcher)
+		if !ok {
+			http.Error(w, "search is not supported", http.StatusNotImplemented)
+			return
+		}
+
+		limit := defaultSearchLimit
+		if s := r.FormValue("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+			if n > 0 {
+				limit = minInt(n, maxSearchLimit)
+			}
+		}
+		query := r.FormValue("q")
+		if utf8.RuneCountInString(query) > maxSearchQuery {
+			http.Error(w, "query too long", http.StatusBadRequest)
+			return
+		}
+
+		matches := searcher.Search(query, limit)
+		if matches == nil {
+			matches = []Match{}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(matches)
+	})
+}
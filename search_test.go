@@ -0,0 +1,127 @@
+package zipfs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestZipFS_Search(t *testing.T) {
+	fs := &zipFS{trie: newTrie()}
+	for _, key := range []string{
+		"/docs/getting-started/index.html",
+		"/docs/api/IndexBuilder.html",
+		"/docs/index.html",
+		"/vendor/jquery/dist/jquery.min.js",
+		"/assets/js/main.js",
+		"/assets/js/min.js",
+	} {
+		fs.trie.Add(key, &zipEntry{})
+	}
+	fs.trie.Add("/docs", zipDir{})
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"index", []string{"/docs/index.html", "/docs/api/IndexBuilder.html", "/docs/getting-started/index.html"}},
+		{"ib", []string{"/docs/api/IndexBuilder.html"}},
+		{"minjs", []string{"/assets/js/min.js", "/vendor/jquery/dist/jquery.min.js", "/assets/js/main.js"}},
+		{"docs", []string{"/docs/index.html", "/docs/api/IndexBuilder.html", "/docs/getting-started/index.html"}},
+		{"zzz", nil},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		matches := fs.Search(test.query, 0)
+		if len(matches) != len(test.expected) {
+			t.Errorf("Search(%q): expected %v got %v", test.query, test.expected, matches)
+			continue
+		}
+		for i, m := range matches {
+			if m.Path != test.expected[i] {
+				t.Errorf("Search(%q): expected %v got %v", test.query, test.expected, matches)
+				break
+			}
+		}
+	}
+
+	if matches := fs.Search("index", 1); len(matches) != 1 {
+		t.Errorf("Expected 1 match got %d", len(matches))
+	}
+}
+
+func TestScorePathPositions(t *testing.T) {
+	score, positions := scorePath("/docs/api/IndexBuilder.html", []rune("ib"))
+	if score <= 0 {
+		t.Errorf("Expected positive score got %d", score)
+	}
+	if len(positions) != 2 || positions[0] != 10 || positions[1] != 15 {
+		t.Errorf("Expected [10 15] got %v", positions)
+	}
+
+	_, positions = scorePath("/文档/说明.txt", []rune("说明"))
+	if len(positions) != 2 || positions[0] != 4 || positions[1] != 5 {
+		t.Errorf("Expected [4 5] got %v", positions)
+	}
+}
+
+func TestSearchHandler(t *testing.T) {
+	handler := SearchHandler(InitZipFs("testdata/compressed.zip"))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/?q=text6", nil))
+	var matches []Match
+	if err := json.NewDecoder(w.Body).Decode(&matches); err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Path != "/dirA/dirC/text6.txt" {
+		t.Errorf("Expected /dirA/dirC/text6.txt got %v", matches)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/?q=txt&limit=2", nil))
+	matches = nil
+	json.NewDecoder(w.Body).Decode(&matches)
+	if len(matches) != 2 {
+		t.Errorf("Expected 2 matches got %v", matches)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/?q=txt&limit=x", nil))
+	if w.Code != 400 {
+		t.Errorf("Expected 400 got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/?q="+strings.Repeat("a", maxSearchQuery+1), nil))
+	if w.Code != 400 {
+		t.Errorf("Expected 400 for a long query got %d", w.Code)
+	}
+
+	searcher := &limitSearcher{}
+	for query, expected := range map[string]int{"": 20, "limit=0": 20, "limit=5": 5, "limit=1000": maxSearchLimit} {
+		SearchHandler(searcher).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/?"+query, nil))
+		if searcher.limit != expected {
+			t.Errorf("%q: expected limit %d got %d", query, expected, searcher.limit)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	SearchHandler(Prefix("/dirA", InitZipFs("testdata/compressed.zip"))).ServeHTTP(w, httptest.NewRequest("GET", "/?q=txt", nil))
+	if w.Code != 501 {
+		t.Errorf("Expected 501 got %d", w.Code)
+	}
+}
+
+type limitSearcher struct {
+	http.Dir
+	limit int
+}
+
+func (s *limitSearcher) Search(query string, limit int) []Match {
+	s.limit = limit
+	return nil
+}
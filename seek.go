@@ -0,0 +1,155 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// Let deflated files seek, for serving ranges of them, by reading from the closest of
+// the points recorded about every span bytes of their content. The points of a file
+// are recorded by reading it whole on its first seek beyond the first span, and are
+// cached up to maxBytes in total, each point costing up to 32KiB. Files with more points
+// than fit in maxBytes keep fewer of them, further apart.
+func SeekPoints(span int64, maxBytes int64) Option {
+	return func(fs *zipFS) {
+		fs.seekSpan = span
+		fs.seekIndexes = newLRU(maxBytes)
+	}
+}
+
+// Position in a deflate stream where decoding can start, given the output before it.
+type seekPoint struct {
+	out     int64
+	offset  int64
+	history []byte
+}
+
+// Return the seek points of the deflated entry, recording them when they are not in the
+// cache. The whole content is checked against the size and CRC32 of the entry meanwhile.
+func (fs *zipFS) seekPoints(entry *zipEntry, raw io.ReaderAt) ([]seekPoint, error) {
+	if points, ok := fs.seekIndexes.Get(entry.Name); ok {
+		return points.([]seekPoint), nil
+	}
+
+	f := newInflater(raw, int64(entry.CompressedSize64), 0, 0, nil)
+	points := []seekPoint{{}}
+	var cost int64
+	f.onBlock = func() {
+		if f.out-points[len(points)-1].out >= fs.seekSpan {
+			history := f.history()
+			points = append(points, seekPoint{out: f.out, offset: f.bitOffset(), history: history})
+			cost += int64(len(history))
+		}
+	}
+	crc := crc32.NewIEEE()
+	r := &limitedReader{ReadCloser: io.NopCloser(f), remaining: entry.UncompressedSize64}
+	n, err := io.Copy(crc, r)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(n) != entry.UncompressedSize64 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if entry.CRC32 != 0 && crc.Sum32() != entry.CRC32 {
+		return nil, zip.ErrChecksum
+	}
+	for cost > fs.seekIndexes.capacity && len(points) > 1 {
+		points, cost = thinSeekPoints(points)
+	}
+	fs.seekIndexes.Add(entry.Name, points, cost)
+	return points, nil
+}
+
+// Drop every other point after the first, doubling the span between them.
+func thinSeekPoints(points []seekPoint) ([]seekPoint, int64) {
+	var cost int64
+	thinned := points[:1]
+	for i := 2; i < len(points); i += 2 {
+		thinned = append(thinned, points[i])
+		cost += int64(len(points[i].history))
+	}
+	return thinned, cost
+}
+
+// Reads a deflated file from the seek point before the position, see SeekPoints.
+type seekableReader struct {
+	fs    *zipFS
+	entry *zipEntry
+	// The compressed content.
+	raw io.ReaderAt
+	// Reader at pos, nil until the first read.
+	r   io.Reader
+	pos int64
+	// Position of the next read.
+	seek int64
+	crc  *crcVerifier
+}
+
+func (s *seekableReader) Close() error { return nil }
+
+func (s *seekableReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.seek
+	case io.SeekEnd:
+		offset += int64(s.entry.UncompressedSize64)
+	}
+	if offset < 0 {
+		return 0, errors.New("zipfs: negative position")
+	}
+	s.seek = offset
+	return offset, nil
+}
+
+func (s *seekableReader) Read(b []byte) (int, error) {
+	if s.r == nil || s.seek != s.pos {
+		if err := s.position(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.r.Read(b)
+	if cerr := s.crc.read(s.entry, s.pos, b[:n]); cerr != nil {
+		err = cerr
+	}
+	s.pos += int64(n)
+	s.seek = s.pos
+	return n, err
+}
+
+// Move the reader to the position of the next read, reading on from where it is when
+// that is closer than the seek point before the position.
+func (s *seekableReader) position() error {
+	target := s.seek
+	ahead := s.r != nil && s.pos <= target
+	if !ahead || target-s.pos >= s.fs.seekSpan {
+		p := seekPoint{}
+		if target >= s.fs.seekSpan {
+			points, err := s.fs.seekPoints(s.entry, s.raw)
+			if err != nil {
+				return err
+			}
+			for _, point := range points {
+				if point.out > target {
+					break
+				}
+				p = point
+			}
+		}
+		if !ahead || p.out > s.pos {
+			f := newInflater(s.raw, int64(s.entry.CompressedSize64), p.offset, p.out, p.history)
+			s.r = &limitedReader{ReadCloser: io.NopCloser(f), remaining: s.entry.UncompressedSize64 - uint64(p.out)}
+			s.pos = p.out
+		}
+	}
+
+	n, err := io.CopyN(io.Discard, s.r, target-s.pos)
+	s.pos += n
+	if err == io.EOF {
+		// Reading beyond the end.
+		s.pos = target
+		return nil
+	}
+	return err
+}
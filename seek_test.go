@@ -0,0 +1,110 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestZipFS_SeekPoints(t *testing.T) {
+	content := inflateInput(1 << 20)
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, _ := w.Create("video.mp4")
+	f.Write(content)
+	w.Close()
+	r := bytes.NewReader(buf.Bytes())
+	z, _ := zip.NewReader(r, r.Size())
+	fs := NewZipFSWithReaderAt(z, r, SeekPoints(64<<10, 1<<20)).(*zipFS)
+
+	file := Must(fs.Open("/video.mp4"))
+	if end, err := file.Seek(0, io.SeekEnd); end != int64(len(content)) || err != nil {
+		t.Errorf("Expected end at %d got %d %v", len(content), end, err)
+	}
+	// Seeking within the first span reads from the start.
+	file.Seek(1000, io.SeekStart)
+	b := make([]byte, 100)
+	io.ReadFull(file, b)
+	if !bytes.Equal(b, content[1000:1100]) {
+		t.Error("Expected content at 1000")
+	}
+	if _, ok := fs.seekIndexes.Get("video.mp4"); ok {
+		t.Error("Expected no seek points recorded")
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		offset := rnd.Int63n(int64(len(content)))
+		whence := io.SeekStart
+		if i%2 == 1 {
+			offset -= int64(len(content))
+			whence = io.SeekEnd
+		}
+		pos, err := file.Seek(offset, whence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := io.ReadFull(file, b)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b[:n], content[pos:pos+int64(n)]) {
+			t.Fatalf("Expected content at %d", pos)
+		}
+	}
+	points, ok := fs.seekIndexes.Get("video.mp4")
+	if !ok || len(points.([]seekPoint)) < 4 {
+		t.Errorf("Expected seek points recorded got %v", ok)
+	}
+
+	// Whole reads after seeking are checked.
+	file.Seek(0, io.SeekStart)
+	if all, err := io.ReadAll(file); err != nil || !bytes.Equal(all, content) {
+		t.Errorf("Expected the whole content got %d bytes %v", len(all), err)
+	}
+	file.Seek(10, io.SeekEnd)
+	if n, err := file.Read(b); n != 0 || err != io.EOF {
+		t.Errorf("Expected EOF beyond the end got %d %v", n, err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/video.mp4", nil)
+	req.Header.Set("Range", "bytes=700000-700099")
+	http.FileServer(fs).ServeHTTP(rec, req)
+	if rec.Code != http.StatusPartialContent || !bytes.Equal(rec.Body.Bytes(), content[700000:700100]) {
+		t.Errorf("Expected partial content got %d %q", rec.Code, rec.Body.Bytes())
+	}
+}
+
+// Points that do not fit in the cache are thinned rather than recorded on every seek.
+func TestZipFS_SeekPointsThinned(t *testing.T) {
+	content := inflateInput(1 << 20)
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, _ := w.Create("video.mp4")
+	f.Write(content)
+	w.Close()
+	r := bytes.NewReader(buf.Bytes())
+	z, _ := zip.NewReader(r, r.Size())
+	fs := NewZipFSWithReaderAt(z, r, SeekPoints(64<<10, 100<<10)).(*zipFS)
+
+	file := Must(fs.Open("/video.mp4"))
+	b := make([]byte, 100)
+	for _, offset := range []int64{900000, 300000, 700000} {
+		file.Seek(offset, io.SeekStart)
+		if _, err := io.ReadFull(file, b); err != nil || !bytes.Equal(b, content[offset:offset+100]) {
+			t.Fatalf("Expected content at %d got %v", offset, err)
+		}
+	}
+	points, ok := fs.seekIndexes.Get("video.mp4")
+	if !ok || len(points.([]seekPoint)) < 2 {
+		t.Fatalf("Expected seek points recorded got %v", ok)
+	}
+	if _, cost := fs.seekIndexes.stats(); cost > 100<<10 {
+		t.Errorf("Expected at most %d bytes of seek points got %d", 100<<10, cost)
+	}
+}
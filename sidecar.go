@@ -0,0 +1,219 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+// Suffix of the index sidecar that InitZipFs loads, when present next to the zip file,
+// instead of reading the central directory. Use WriteIndex to create it.
+const IndexSuffix = ".idx"
+
+const indexMagic = "ZIPFSIDX\x01"
+
+var errStaleIndex = errors.New("zipfs: index does not match the archive")
+
+// Write an index of the size bytes of the zip archive in r to w. The index holds the
+// header of every file along with the offset of its data, so loading it skips parsing
+// the central directory and reading the local headers. It is only used while the
+// central directory it was written from is unchanged.
+func WriteIndex(w io.Writer, r io.ReaderAt, size int64) error {
+	end, err := readDirectoryEnd(r, size)
+	if err != nil {
+		return err
+	}
+	sum, err := directoryChecksum(r, end)
+	if err != nil {
+		return err
+	}
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+	iw := &indexWriter{w: bw}
+	bw.WriteString(indexMagic)
+	iw.uvarint(uint64(size))
+	iw.uvarint(uint64(end.dirOffset))
+	iw.uvarint(uint64(end.dirSize))
+	iw.uvarint(uint64(sum))
+	iw.uvarint(uint64(len(z.File)))
+	for _, f := range z.File {
+		offset, err := f.DataOffset()
+		if err != nil {
+			return err
+		}
+		iw.bytes([]byte(f.Name))
+		iw.bytes(f.Extra)
+		iw.uvarint(uint64(f.CreatorVersion))
+		iw.uvarint(uint64(f.ReaderVersion))
+		iw.uvarint(uint64(f.Flags))
+		iw.uvarint(uint64(f.Method))
+		iw.uvarint(uint64(f.ExternalAttrs))
+		_, zone := f.Modified.Zone()
+		iw.varint(f.Modified.Unix())
+		iw.uvarint(uint64(f.Modified.Nanosecond()))
+		iw.varint(int64(zone))
+		iw.uvarint(uint64(f.CRC32))
+		iw.uvarint(f.CompressedSize64)
+		iw.uvarint(f.UncompressedSize64)
+		iw.uvarint(uint64(offset))
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	var trailer [4]byte
+	binary.LittleEndian.PutUint32(trailer[:], crc.Sum32())
+	_, err = w.Write(trailer[:])
+	return err
+}
+
+// Load the entries from the index file at name, if it was written from the size bytes
+// of the zip archive in r.
+func loadIndex(name string, r io.ReaderAt, size int64) ([]*zipEntry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < len(indexMagic)+4 || string(b[:len(indexMagic)]) != indexMagic {
+		return nil, errStaleIndex
+	}
+	n := len(b) - 4
+	if crc32.ChecksumIEEE(b[:n]) != binary.LittleEndian.Uint32(b[n:]) {
+		return nil, errStaleIndex
+	}
+
+	ir := &indexReader{r: bytes.NewReader(b[len(indexMagic):n])}
+	indexSize, dirOffset, dirSize, sum := ir.uvarint(), ir.uvarint(), ir.uvarint(), ir.uvarint()
+	if ir.err != nil || indexSize != uint64(size) {
+		return nil, errStaleIndex
+	}
+	end, err := readDirectoryEnd(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if dirOffset != uint64(end.dirOffset) || dirSize != uint64(end.dirSize) {
+		return nil, errStaleIndex
+	}
+	if actual, err := directoryChecksum(r, end); err != nil || uint64(actual) != sum {
+		return nil, errStaleIndex
+	}
+
+	count := ir.uvarint()
+	if ir.err != nil || count > uint64(n) {
+		return nil, errStaleIndex
+	}
+	entries := make([]*zipEntry, 0, count)
+	for i := uint64(0); i < count; i++ {
+		h := &zip.FileHeader{
+			Name:           string(ir.bytes()),
+			Extra:          ir.bytes(),
+			CreatorVersion: uint16(ir.uvarint()),
+			ReaderVersion:  uint16(ir.uvarint()),
+			Flags:          uint16(ir.uvarint()),
+			Method:         uint16(ir.uvarint()),
+			ExternalAttrs:  uint32(ir.uvarint()),
+		}
+		sec, nsec, zone := ir.varint(), ir.uvarint(), ir.varint()
+		h.Modified = time.Unix(sec, int64(nsec)).In(time.FixedZone("", int(zone)))
+		h.CRC32 = uint32(ir.uvarint())
+		h.CompressedSize64 = ir.uvarint()
+		h.UncompressedSize64 = ir.uvarint()
+		h.CompressedSize = uint32(min64(h.CompressedSize64, 0xffffffff))
+		h.UncompressedSize = uint32(min64(h.UncompressedSize64, 0xffffffff))
+		entries = append(entries, &zipEntry{FileHeader: h, offset: int64(ir.uvarint())})
+	}
+	if ir.err != nil || ir.r.Len() != 0 {
+		return nil, errStaleIndex
+	}
+	return entries, nil
+}
+
+// CRC32 of the central directory described by end.
+func directoryChecksum(r io.ReaderAt, end *directoryEnd) (uint32, error) {
+	crc := crc32.NewIEEE()
+	_, err := io.Copy(crc, io.NewSectionReader(r, end.base+end.dirOffset, end.dirSize))
+	return crc.Sum32(), err
+}
+
+func min64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type indexWriter struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (w *indexWriter) uvarint(v uint64) {
+	w.w.Write(w.buf[:binary.PutUvarint(w.buf[:], v)])
+}
+
+func (w *indexWriter) varint(v int64) {
+	w.w.Write(w.buf[:binary.PutVarint(w.buf[:], v)])
+}
+
+func (w *indexWriter) bytes(b []byte) {
+	w.uvarint(uint64(len(b)))
+	w.w.Write(b)
+}
+
+// Reads the values written by indexWriter, keeping the first error.
+type indexReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (r *indexReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(r.r)
+	r.err = err
+	return v
+}
+
+func (r *indexReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(r.r)
+	r.err = err
+	return v
+}
+
+func (r *indexReader) bytes() []byte {
+	n := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(r.r.Len()) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	if n == 0 {
+		return nil
+	}
+	b := make([]byte, n)
+	r.r.Read(b)
+	return b
+}
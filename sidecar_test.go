@@ -0,0 +1,83 @@
+package zipfs
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Copies the zip file to a temporary directory, along with an index of it.
+func indexedCopy(t *testing.T, name string) string {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), filepath.Base(name))
+	os.WriteFile(dst, b, 0644)
+
+	index := &bytes.Buffer{}
+	if err := WriteIndex(index, bytes.NewReader(b), int64(len(b))); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(dst+IndexSuffix, index.Bytes(), 0644)
+	return dst
+}
+
+func TestInitZipFs_Index(t *testing.T) {
+	for _, name := range []string{"testdata/compressed.zip", "testdata/uncompressed.zip"} {
+		expected := readAll(t, InitZipFs(name), "/dirA/dirC/text6.txt")
+
+		fs := InitZipFs(indexedCopy(t, name)).(*zipFS)
+		node, _ := fs.trie.Find("/dirA/dirC/text6.txt")
+		if node.meta.(*zipEntry).file != nil {
+			t.Errorf("%s: expected the entry to be loaded from the index", name)
+		}
+		if actual := readAll(t, fs, "/dirA/dirC/text6.txt"); !bytes.Equal(actual, expected) {
+			t.Errorf("%s: content differs when loaded from the index", name)
+		}
+		dir, err := fs.Open("/dirA")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if infos, _ := dir.Readdir(-1); len(infos) != 3 {
+			t.Errorf("%s: expected 3 entries got %d", name, len(infos))
+		}
+	}
+}
+
+func TestInitZipFs_StaleIndex(t *testing.T) {
+	name := indexedCopy(t, "testdata/compressed.zip")
+	stale := indexedCopy(t, "testdata/uncompressed.zip")
+	os.Rename(stale+IndexSuffix, name+IndexSuffix)
+
+	fs := InitZipFs(name).(*zipFS)
+	node, _ := fs.trie.Find("/text1.txt")
+	if node.meta.(*zipEntry).file == nil {
+		t.Error("Expected a stale index to be ignored")
+	}
+
+	b, _ := os.ReadFile(name + IndexSuffix)
+	b[len(b)/2] ^= 0xff
+	os.WriteFile(name+IndexSuffix, b, 0644)
+	f, _ := os.Open(name)
+	fi, _ := f.Stat()
+	if _, err := loadIndex(name+IndexSuffix, f, fi.Size()); err != errStaleIndex {
+		t.Errorf("Expected errStaleIndex got %v", err)
+	}
+}
+
+func readAll(t *testing.T, fs http.FileSystem, name string) []byte {
+	f, err := fs.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,112 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Prefix of the archive comment holding the signature written by Sign.
+const signaturePrefix = "zipfs-ed25519:"
+
+// Error returned when loading an archive whose signature is missing or does not match
+// the public key.
+var ErrSignature = errors.New("zipfs: archive signature is missing or invalid")
+
+// Refuse to load archives that are not signed by the private key of pub, see Sign.
+// The signature covers the central directory, which holds the name, sizes and CRC32
+// of every file, so CRC32 verification of stored reads is turned on as well. It is
+// checked by InitZipFs and the other loaders reading the archive themselves, and by
+// the constructors when the readerAt has a Size method.
+func Signed(pub ed25519.PublicKey) Option {
+	return func(fs *zipFS) {
+		fs.publicKey = pub
+		fs.verifyCRC = true
+	}
+}
+
+// Write a copy of the size bytes of the zip archive in r to w, with its comment replaced
+// by an ed25519 signature of its central directory. The output can still be appended to
+// an application, as the signature does not depend on where the archive starts.
+func Sign(w io.Writer, r io.ReaderAt, size int64, key ed25519.PrivateKey) error {
+	end, err := readDirectoryEnd(r, size)
+	if err != nil {
+		return err
+	}
+	dir, err := readDirectory(r, end)
+	if err != nil {
+		return err
+	}
+	comment := signaturePrefix + base64.StdEncoding.EncodeToString(ed25519.Sign(key, dir))
+
+	// Everything up to the comment length in the end of central directory record.
+	if _, err := io.Copy(w, io.NewSectionReader(r, 0, end.offset+directoryEndLen-2)); err != nil {
+		return err
+	}
+	var n [2]byte
+	binary.LittleEndian.PutUint16(n[:], uint16(len(comment)))
+	if _, err := w.Write(n[:]); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, comment)
+	return err
+}
+
+// Check the signature of the size bytes of the zip archive in r against pub.
+func VerifySignature(r io.ReaderAt, size int64, pub ed25519.PublicKey) error {
+	end, err := readDirectoryEnd(r, size)
+	if err != nil {
+		return err
+	}
+	comment := string(end.comment)
+	if !strings.HasPrefix(comment, signaturePrefix) {
+		return ErrSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(comment[len(signaturePrefix):])
+	if err != nil {
+		return ErrSignature
+	}
+	dir, err := readDirectory(r, end)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, dir, sig) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// Check the signature of the archive in r, when the file system requires one.
+func (fs *zipFS) verifySignature(r io.ReaderAt, size int64) error {
+	if fs.publicKey == nil {
+		return nil
+	}
+	if r == nil || size < 0 {
+		return ErrSignature
+	}
+	return VerifySignature(r, size, fs.publicKey)
+}
+
+// Size of r, or -1 when it does not tell.
+func readerSize(r io.ReaderAt) int64 {
+	if s, ok := r.(interface{ Size() int64 }); ok {
+		return s.Size()
+	}
+	return -1
+}
+
+// Read the central directory described by end.
+func readDirectory(r io.ReaderAt, end *directoryEnd) ([]byte, error) {
+	if end.dirSize < 0 || end.base+end.dirOffset < 0 || end.dirSize > end.offset {
+		return nil, zip.ErrFormat
+	}
+	dir := make([]byte, end.dirSize)
+	if _, err := r.ReadAt(dir, end.base+end.dirOffset); err != nil {
+		return nil, err
+	}
+	return dir, nil
+}
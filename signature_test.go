@@ -0,0 +1,146 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	other, _, _ := ed25519.GenerateKey(nil)
+
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, _ := w.Create("a.txt")
+	f.Write([]byte("hello"))
+	w.SetComment("replaced")
+	w.Close()
+	unsigned := buf.Bytes()
+
+	signed := &bytes.Buffer{}
+	if err := Sign(signed, bytes.NewReader(unsigned), int64(len(unsigned)), key); err != nil {
+		t.Fatal(err)
+	}
+	// Appended to an application, as GetEmbeddedZip finds it.
+	appended := append([]byte("application"), signed.Bytes()...)
+
+	tampered := append([]byte{}, signed.Bytes()...)
+	dir := bytes.LastIndex(tampered, []byte("a.txt"))
+	tampered[dir] = 'b'
+
+	tests := []struct {
+		archive  []byte
+		pub      ed25519.PublicKey
+		expected error
+	}{
+		{signed.Bytes(), pub, nil},
+		{appended, pub, nil},
+		{signed.Bytes(), other, ErrSignature},
+		{unsigned, pub, ErrSignature},
+		{tampered, pub, ErrSignature},
+	}
+	for i, test := range tests {
+		r := bytes.NewReader(test.archive)
+		if err := VerifySignature(r, r.Size(), test.pub); err != test.expected {
+			t.Errorf("%d: expected %v got %v", i, test.expected, err)
+		}
+		if _, err := openZipFS(r, r.Size(), "", []Option{Signed(test.pub)}); err != test.expected {
+			t.Errorf("%d: expected %v loading got %v", i, test.expected, err)
+		}
+	}
+
+	r := bytes.NewReader(signed.Bytes())
+	z, _ := zip.NewReader(r, r.Size())
+	fs, err := OpenZipFS(z, r, Signed(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content := readAll(t, fs, "/a.txt"); string(content) != "hello" {
+		t.Errorf("Expected hello got %q", content)
+	}
+	if _, err := OpenZipFS(z, nil, Signed(pub)); err != ErrSignature {
+		t.Errorf("Expected %v without a readerAt got %v", ErrSignature, err)
+	}
+}
+
+// A forged index would otherwise replace the signed central directory.
+func TestInitZipFs_SignedIgnoresIndex(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, _ := w.Create("a.txt")
+	f.Write([]byte("hello"))
+	w.Close()
+	signed := &bytes.Buffer{}
+	if err := Sign(signed, bytes.NewReader(buf.Bytes()), int64(buf.Len()), key); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "signed.zip")
+	os.WriteFile(name, signed.Bytes(), 0644)
+
+	index := &bytes.Buffer{}
+	if err := WriteIndex(index, bytes.NewReader(signed.Bytes()), int64(signed.Len())); err != nil {
+		t.Fatal(err)
+	}
+	forged := index.Bytes()
+	forged[bytes.Index(forged, []byte("a.txt"))] = 'b'
+	n := len(forged) - 4
+	binary.LittleEndian.PutUint32(forged[n:], crc32.ChecksumIEEE(forged[:n]))
+	os.WriteFile(name+IndexSuffix, forged, 0644)
+
+	if _, err := InitZipFs(name).Open("/b.txt"); err != nil {
+		t.Fatalf("Expected the forged index to be loaded without a key: %v", err)
+	}
+	fs := InitZipFs(name, Signed(pub))
+	if _, err := fs.Open("/b.txt"); err == nil {
+		t.Error("Expected the forged index to be ignored")
+	}
+	if content := readAll(t, fs, "/a.txt"); string(content) != "hello" {
+		t.Errorf("Expected hello got %q", content)
+	}
+}
+
+// Archives refused by the options panic, rather than falling back to the embedded zip.
+func TestInitZipFs_Refused(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	notZip := filepath.Join(t.TempDir(), "not.zip")
+	os.WriteFile(notZip, []byte("not a zip file"), 0644)
+	b, _ := os.ReadFile("testdata/compressed.zip")
+	b[bytes.Index(b, []byte("PK\x01\x02"))] = 'X'
+	corrupt := filepath.Join(t.TempDir(), "corrupt.zip")
+	os.WriteFile(corrupt, b, 0644)
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected error
+	}{
+		{"testdata/compressed.zip", []Option{Signed(pub)}, ErrSignature},
+		{"testdata/compressed.zip", []Option{Limit(Limits{Entries: 1})}, &LimitError{Limit: "Entries"}},
+		{notZip, nil, nil},
+		{notZip, []Option{Signed(pub)}, nil},
+		{corrupt, nil, nil},
+	}
+	for _, test := range tests {
+		_, _, embeddedErr := GetEmbeddedZip()
+		func() {
+			defer func() {
+				expected := test.expected
+				if expected == nil {
+					// Fell back to the embedded zip, which the test binary does not have.
+					expected = embeddedErr
+				}
+				if err := recover(); err != expected.Error() {
+					t.Errorf("%s: expected a panic with %v got %v", test.name, expected, err)
+				}
+			}()
+			InitZipFs(test.name, test.opts...)
+		}()
+	}
+}
@@ -0,0 +1,76 @@
+package zipfs
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// Serve requests for paths the file system has with h, and navigation requests for the
+// other paths with document, such as "/index.html", for single page applications that
+// route on the client. Navigation requests are GET or HEAD requests accepting HTML for
+// paths without an extension, or with an HTML one. Paths under the exclude prefixes,
+// such as "/api", are always served by h.
+func SPAHandler(fileSystem http.FileSystem, h http.Handler, document string, exclude ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		if !navigation(r, name) || excluded(name, exclude) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		f, err := fileSystem.Open(name)
+		if err == nil {
+			f.Close()
+		}
+		if !os.IsNotExist(err) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		serveDocument(w, r, fileSystem, document)
+	})
+}
+
+func navigation(r *http.Request, name string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if ext := strings.ToLower(path.Ext(name)); ext != "" && ext != ".html" && ext != ".htm" {
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	return accept == "" || strings.Contains(accept, "text/html") || strings.Contains(accept, "*/*")
+}
+
+func excluded(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = "/" + strings.Trim(prefix, "/")
+		if name == prefix || strings.HasPrefix(name, prefix+"/") || prefix == "/" {
+			return true
+		}
+	}
+	return false
+}
+
+// Serve the document whole, as compressed files cannot seek for http.ServeContent.
+func serveDocument(w http.ResponseWriter, r *http.Request, fileSystem http.FileSystem, document string) {
+	f, err := fileSystem.Open(document)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), bytes.NewReader(b))
+}
@@ -0,0 +1,40 @@
+package zipfs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSPAHandler(t *testing.T) {
+	fs := NewZipFS(zipOf("index.html", "app.js", "docs/guide.html"))
+	h := SPAHandler(fs, http.FileServer(fs), "/index.html", "/api")
+
+	tests := []struct {
+		method, target, accept string
+		code                   int
+		body                   string
+	}{
+		{"GET", "/app.js", "*/*", 200, "app.js"},
+		{"GET", "/docs/guide.html", "text/html", 200, "docs/guide.html"},
+		{"GET", "/users/42", "text/html,application/xhtml+xml", 200, "index.html"},
+		{"GET", "/users/42", "", 200, "index.html"},
+		{"HEAD", "/settings/profile.html", "text/html", 200, ""},
+		{"GET", "/users/42", "application/json", 404, "404 page not found\n"},
+		{"GET", "/missing.js", "*/*", 404, "404 page not found\n"},
+		{"POST", "/users/42", "text/html", 404, "404 page not found\n"},
+		{"GET", "/api/users", "text/html", 404, "404 page not found\n"},
+		{"GET", "/apiary", "text/html", 200, "index.html"},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(test.method, test.target, nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		h.ServeHTTP(rec, req)
+		if rec.Code != test.code || rec.Body.String() != test.body {
+			t.Errorf("%s %s %s: expected %d %q got %d %q", test.method, test.target, test.accept, test.code, test.body, rec.Code, rec.Body.String())
+		}
+	}
+}
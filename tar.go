@@ -0,0 +1,126 @@
+package zipfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Initialise a File System based on the given tar or gzip compressed tar file name,
+// it will panic when the file cannot be read.
+func InitTarFs(tarFileName string, opts ...Option) http.FileSystem {
+	f, err := os.Open(tarFileName)
+	if err != nil {
+		log.Panic(err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	fs, err := NewTarFS(f, fi.Size(), opts...)
+	if err != nil {
+		log.Panic(err)
+	}
+	return fs
+}
+
+// Size up to which gzip compressed tar archives are decompressed to a temporary file,
+// unless Spill is given.
+const defaultTarSpillSize = 4 << 30
+
+// Create a File System from the size bytes of the tar archive in r, which may be gzip
+// compressed. The files of a tar archive are read in place and can seek, a compressed
+// archive is decompressed whole first, in memory up to 32MiB and to a temporary file of
+// up to 4GiB beyond that, unless set otherwise with Spill. Any error reading the archive
+// is returned, unless the file system is lazy.
+func NewTarFS(r io.ReaderAt, size int64, opts ...Option) (http.FileSystem, error) {
+	fs := newZipFS(r, append([]Option{Spill(defaultSpillMemory, defaultTarSpillSize)}, opts...))
+	err := fs.open(func() ([]*zipEntry, error) {
+		// Signatures cover the central directory of zip archives only.
+		if fs.publicKey != nil {
+			return nil, ErrSignature
+		}
+		if gzipped(r) {
+			gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+			if err != nil {
+				return nil, err
+			}
+			if fs.readerAt, size, err = fs.spill(gz, -1); err != nil {
+				return nil, err
+			}
+		}
+		return fs.tarEntries(size)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+func gzipped(r io.ReaderAt) bool {
+	magic := make([]byte, 2)
+	_, err := r.ReadAt(magic, 0)
+	return err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b})
+}
+
+// Read the headers of the tar archive of size bytes in the readerAt, as stored zip entries
+// at the offset of their data. Entries other than files and directories are skipped.
+func (fs *zipFS) tarEntries(size int64) ([]*zipEntry, error) {
+	sr := io.NewSectionReader(fs.readerAt, 0, size)
+	tr := tar.NewReader(sr)
+
+	var entries []*zipEntry
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := fs.limits.checkEntries(uint64(len(entries) + 1)); err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimPrefix(hdr.Name, "./")
+		if name == "" || name == "." || sparse(hdr) {
+			continue
+		}
+		fh := &zip.FileHeader{Name: name, Modified: hdr.ModTime, Method: zip.Store}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			fh.Name = strings.TrimRight(name, "/") + "/"
+			fh.SetMode(os.ModeDir | hdr.FileInfo().Mode().Perm())
+		case tar.TypeReg:
+			fh.SetMode(hdr.FileInfo().Mode().Perm())
+			fh.CompressedSize64 = uint64(hdr.Size)
+			fh.UncompressedSize64 = uint64(hdr.Size)
+		default:
+			continue
+		}
+
+		// The reader is at the data, right after the headers of the entry.
+		offset, _ := sr.Seek(0, io.SeekCurrent)
+		entries = append(entries, &zipEntry{FileHeader: fh, offset: offset})
+	}
+}
+
+// Sparse files are not stored as a whole, so they cannot be read in place.
+func sparse(hdr *tar.Header) bool {
+	if hdr.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range hdr.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
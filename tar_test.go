@@ -0,0 +1,118 @@
+package zipfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Creates a tar archive of ./index.html, a symlink and assets/app.js, with the assets
+// directory implicit.
+func tarArchive() []byte {
+	buf := &bytes.Buffer{}
+	w := tar.NewWriter(buf)
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, f := range []struct{ name, content string }{
+		{"./", ""},
+		{"./index.html", "<h1>Hello</h1>"},
+		{"./assets/app.js", "alert(1)"},
+	} {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content)), ModTime: modified, Typeflag: tar.TypeReg}
+		if f.content == "" {
+			hdr.Typeflag, hdr.Mode = tar.TypeDir, 0755
+		}
+		w.WriteHeader(hdr)
+		io.WriteString(w, f.content)
+	}
+	w.WriteHeader(&tar.Header{Name: "link", Linkname: "index.html", Typeflag: tar.TypeSymlink, ModTime: modified})
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestNewTarFS(t *testing.T) {
+	archive := tarArchive()
+	gzipped := &bytes.Buffer{}
+	gw := gzip.NewWriter(gzipped)
+	gw.Write(archive)
+	gw.Close()
+
+	for _, b := range [][]byte{archive, gzipped.Bytes()} {
+		for _, opts := range [][]Option{nil, {Lazy(1)}, {Spill(0, 1<<20)}} {
+			fs, err := NewTarFS(bytes.NewReader(b), int64(len(b)), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if content := readAll(t, fs, "/index.html"); string(content) != "<h1>Hello</h1>" {
+				t.Errorf("Expected index.html got %q", content)
+			}
+			file := Must(fs.Open("/assets/app.js"))
+			file.Seek(5, io.SeekStart)
+			if content, _ := io.ReadAll(file); string(content) != "(1)" {
+				t.Errorf("Expected (1) after seeking got %q", content)
+			}
+			if fi, _ := file.Stat(); fi.Size() != 8 || fi.Mode() != 0644 || !fi.ModTime().Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+				t.Errorf("Expected app.js info got %v %v %v", fi.Size(), fi.Mode(), fi.ModTime())
+			}
+
+			root := Must(fs.Open("/"))
+			infos, _ := root.Readdir(-1)
+			if len(infos) != 2 {
+				t.Errorf("Expected index.html and assets listed got %d entries", len(infos))
+			}
+			if _, err := fs.Open("/link"); !os.IsNotExist(err) {
+				t.Errorf("Expected the symlink skipped got %v", err)
+			}
+		}
+	}
+
+	if _, err := NewTarFS(bytes.NewReader(archive), int64(len(archive)), Limit(Limits{Entries: 2})); err == nil {
+		t.Error("Expected the entries limit exceeded")
+	}
+}
+
+// Compressed archives larger than the default in memory are decompressed to a temporary file.
+func TestNewTarFS_LargeGzip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gw, _ := gzip.NewWriterLevel(buf, gzip.BestSpeed)
+	w := tar.NewWriter(gw)
+	w.WriteHeader(&tar.Header{Name: "large.bin", Mode: 0644, Size: defaultSpillMemory + 1})
+	io.CopyN(w, zeros{}, defaultSpillMemory+1)
+	w.Close()
+	gw.Close()
+
+	fs, err := NewTarFS(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := Must(fs.Open("/large.bin")).Stat(); fi.Size() != defaultSpillMemory+1 {
+		t.Errorf("Expected %d bytes got %d", defaultSpillMemory+1, fi.Size())
+	}
+	if _, err := NewTarFS(bytes.NewReader(buf.Bytes()), int64(buf.Len()), Spill(defaultSpillMemory, 0)); err != ErrSpillLimit {
+		t.Errorf("Expected %v without temporary files got %v", ErrSpillLimit, err)
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
+
+func TestInitTarFs(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "assets.tar")
+	if err := os.WriteFile(name, tarArchive(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fs := InitTarFs(name)
+	if content := readAll(t, fs, "/assets/app.js"); string(content) != "alert(1)" {
+		t.Errorf("Expected app.js got %q", content)
+	}
+}
@@ -1,111 +1,84 @@
-// Implementation of an R-Way trie data structure.
+// Implementation of a compact path index.
 //
-// A trie has a root node which is the base of the tree.
-// Each subsequent node has a letter and children, which are
-// nodes that have letter values associated with them.
+// Keys are kept in a single slice sorted by byte order, so lookups
+// and prefix searches are binary searches and all keys sharing a
+// prefix are stored next to each other. Keys may be added in any
+// order; the slice is sorted by Sort, or else the first time it is
+// searched. Like a map, it is safe to search concurrently once no
+// more keys are added and it is sorted.
 
 package zipfs
 
-import "sort"
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
 
 type node struct {
-	val      rune
-	term     bool
-	depth    int
-	meta     interface{}
-	mask     uint64
-	parent   *node
-	children map[rune]*node
+	key  string
+	meta interface{}
+	mask uint64
 }
 
 type trie struct {
-	root *node
-	size int
+	nodes []*node
+	dirty bool
+	size  int
 }
 
-type byKeys []string
-
-func (a byKeys) Len() int           { return len(a) }
-func (a byKeys) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a byKeys) Less(i, j int) bool { return len(a[i]) < len(a[j]) }
-
-const nul = 0x0
-
-// Creates a newTrie trie with an initialized root node.
+// Creates a newTrie trie with no keys.
 func newTrie() *trie {
-	return &trie{
-		root: &node{children: make(map[rune]*node), depth: 0},
-		size: 0,
-	}
-}
-
-// Returns the root node for the trie.
-func (t *trie) Root() *node {
-	return t.root
+	return &trie{}
 }
 
 // Adds the key to the trie, including meta data. Meta data
 // is stored as `interface{}` and must be type cast by
-// the caller.
+// the caller. Adding a key that already exists replaces its
+// meta data.
 func (t *trie) Add(key string, meta interface{}) *node {
-	t.size++
-	runes := []rune(key)
-	bitmask := maskruneslice(runes)
-	node := t.root
-	node.mask |= bitmask
-	for i := range runes {
-		r := runes[i]
-		bitmask = maskruneslice(runes[i:])
-		if n, ok := node.children[r]; ok {
-			node = n
-			node.mask |= bitmask
-		} else {
-			node = node.NewChild(r, bitmask, nil, false)
+	n := &node{key: key, meta: meta, mask: maskstring(key)}
+	if l := len(t.nodes); l > 0 && !t.dirty {
+		switch last := t.nodes[l-1]; {
+		case last.key == key:
+			last.meta = meta
+			return last
+		case last.key > key:
+			t.dirty = true
 		}
 	}
-	node = node.NewChild(nul, 0, meta, true)
-	return node
+	t.nodes = append(t.nodes, n)
+	t.size++
+	return n
 }
 
 // Finds and returns meta data associated
 // with `key`.
 func (t *trie) Find(key string) (*node, bool) {
-	node := findNode(t.Root(), []rune(key))
-	if node == nil {
-		return nil, false
-	}
-
-	node, ok := node.Children()[nul]
-	if !ok || !node.term {
+	nodes := t.sorted()
+	i := search(nodes, key)
+	if i == len(nodes) || nodes[i].key != key {
 		return nil, false
 	}
 
-	return node, true
+	return nodes[i], true
 }
 
 func (t *trie) HasKeysWithPrefix(key string) bool {
-	node := findNode(t.Root(), []rune(key))
-	return node != nil
+	nodes := t.sorted()
+	i := search(nodes, key)
+	return i < len(nodes) && strings.HasPrefix(nodes[i].key, key)
 }
 
-// Removes a key from the trie, ensuring that
-// all bitmasks up to root are appropriately recalculated.
+// Removes a key from the trie.
 func (t *trie) Remove(key string) {
-	var (
-		i    int
-		rs   = []rune(key)
-		node = findNode(t.Root(), []rune(key))
-	)
-
-	t.size--
-	for n := node.Parent(); n != nil; n = n.Parent() {
-		i++
-		if len(n.Children()) > 1 {
-			r := rs[len(rs)-i]
-			n.RemoveChild(r)
-			break
-		}
+	t.Sort()
+	i := search(t.nodes, key)
+	if i == len(t.nodes) || t.nodes[i].key != key {
+		return
 	}
+	t.nodes = append(t.nodes[:i:i], t.nodes[i+1:]...)
+	t.size--
 }
 
 // Returns all the keys currently stored in the trie.
@@ -113,174 +86,165 @@ func (t *trie) Keys() []string {
 	return t.PrefixSearch("")
 }
 
-// Performs a fuzzy search against the keys in the trie.
-func (t trie) FuzzySearch(pre string) []string {
-	keys := fuzzycollect(t.Root(), []rune(pre))
-	sort.Sort(byKeys(keys))
+// Performs a case insensitive fuzzy search against the keys in the trie.
+func (t *trie) FuzzySearch(pre string) []string {
+	var keys []string
+	for _, n := range t.fuzzyNodes(pre) {
+		keys = append(keys, n.key)
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return len(keys[i]) < len(keys[j]) })
 	return keys
 }
 
+// Returns the nodes whose key contains the runes of `pre` in order, in key order.
+func (t *trie) fuzzyNodes(pre string) []*node {
+	var (
+		partial = []rune(pre)
+		m       = maskstring(pre)
+		nodes   []*node
+	)
+	for _, n := range t.sorted() {
+		if n.mask&m == m && fuzzymatch(n.key, partial) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
 // Performs a prefix search against the keys in the trie.
-func (t trie) PrefixSearch(pre string) []string {
-	node := findNode(t.Root(), []rune(pre))
-	if node == nil {
+func (t *trie) PrefixSearch(pre string) []string {
+	nodes := t.prefixNodes(pre)
+	if nodes == nil {
 		return nil
 	}
 
-	return collect(node)
-}
-
-// Creates and returns a pointer to a newTrie child for the node.
-func (n *node) NewChild(val rune, bitmask uint64, meta interface{}, term bool) *node {
-	node := &node{
-		val:      val,
-		mask:     bitmask,
-		term:     term,
-		meta:     meta,
-		parent:   n,
-		children: make(map[rune]*node),
-		depth:    n.depth + 1,
+	keys := make([]string, len(nodes))
+	for i, n := range nodes {
+		keys[i] = n.key
 	}
-	n.children[val] = node
-	n.mask |= bitmask
-	return node
+	return keys
 }
 
-func (n *node) RemoveChild(r rune) {
-	delete(n.children, r)
-	for nd := n.parent; nd != nil; nd = nd.parent {
-		nd.mask ^= nd.mask
-		nd.mask |= uint64(1) << uint64(nd.val-'a')
-		for _, c := range nd.children {
-			nd.mask |= c.mask
+// Returns the nodes whose key starts with `pre`, in key order.
+func (t *trie) prefixNodes(pre string) []*node {
+	nodes := t.sorted()
+	i := search(nodes, pre)
+	j := i + sort.Search(len(nodes)-i, func(j int) bool {
+		return !strings.HasPrefix(nodes[i+j].key, pre)
+	})
+	if i == j {
+		return nil
+	}
+	return nodes[i:j:j]
+}
+
+// Returns the nodes directly below `dir` in the "/" separated hierarchy of
+// the keys, in key order. Each subtree is skipped with a binary search.
+func (t *trie) childNodes(dir string) []*node {
+	pre := strings.TrimSuffix(dir, "/") + "/"
+	nodes := t.prefixNodes(pre)
+
+	var children []*node
+	for i := 0; i < len(nodes); {
+		rest := nodes[i].key[len(pre):]
+		j := strings.IndexByte(rest, '/')
+		if j < 0 {
+			if rest != "" {
+				children = append(children, nodes[i])
+			}
+			i++
+			continue
 		}
+		// '0' follows '/', so this is the first key past the subtree.
+		i += search(nodes[i:], pre+rest[:j]+"0")
 	}
+	return children
 }
 
-// Returns the parent of this node.
-func (n node) Parent() *node {
-	return n.parent
+// Returns the sorted nodes, sorting them first if keys were
+// added out of order.
+func (t *trie) sorted() []*node {
+	t.Sort()
+	return t.nodes
 }
 
-// Returns the meta information of this node.
-func (n node) Meta() interface{} {
-	return n.meta
-}
+// Sorts the keys added out of order, resolving duplicates. Call it
+// once all the keys are added, before searching concurrently.
+func (t *trie) Sort() {
+	if !t.dirty {
+		return
+	}
 
-// Returns the children of this node.
-func (n node) Children() map[rune]*node {
-	return n.children
-}
+	nodes := t.nodes
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].key < nodes[j].key })
 
-func (n node) Terminating() bool {
-	return n.term
+	// Later additions of the same key replace earlier ones.
+	out := nodes[:0]
+	for _, n := range nodes {
+		if l := len(out); l > 0 && out[l-1].key == n.key {
+			out[l-1] = n
+			continue
+		}
+		out = append(out, n)
+	}
+	for i := len(out); i < len(nodes); i++ {
+		nodes[i] = nil
+	}
+	t.nodes = out
+	t.size = len(out)
+	t.dirty = false
 }
 
-func (n node) Val() rune {
-	return n.val
+// Returns the key of this node.
+func (n *node) Key() string {
+	return n.key
 }
 
-func (n node) Depth() int {
-	return n.depth
+// Returns the meta information of this node.
+func (n *node) Meta() interface{} {
+	return n.meta
 }
 
-// Returns a uint64 representing the current
-// mask of this node.
-func (n node) Mask() uint64 {
+// Returns a uint64 representing the mask of the
+// runes in this node's key.
+func (n *node) Mask() uint64 {
 	return n.mask
 }
 
-func findNode(node *node, runes []rune) *node {
-	if node == nil {
-		return nil
-	}
-
-	if len(runes) == 0 {
-		return node
-	}
-
-	n, ok := node.Children()[runes[0]]
-	if !ok {
-		return nil
-	}
-
-	var nrunes []rune
-	if len(runes) > 1 {
-		nrunes = runes[1:]
-	} else {
-		nrunes = runes[0:0]
-	}
+// Returns the index of the first node whose key is not less than `key`.
+func search(nodes []*node, key string) int {
+	return sort.Search(len(nodes), func(i int) bool { return nodes[i].key >= key })
+}
 
-	return findNode(n, nrunes)
+// Returns the mask bit for a rune. Runes are lower cased and then
+// hashed into one of 64 buckets, so any rune has a bit; runes that
+// share a bucket only cost a subsequence match that fails.
+func maskrune(r rune) uint64 {
+	return uint64(1) << (uint32(unicode.ToLower(r)) * 0x9e3779b9 >> 26)
 }
 
-func maskruneslice(rs []rune) uint64 {
+func maskstring(s string) uint64 {
 	var m uint64
-	for _, r := range rs {
-		m |= uint64(1) << uint64(r-'a')
+	for _, r := range s {
+		m |= maskrune(r)
 	}
 	return m
 }
 
-func collect(_node *node) []string {
-	var (
-		keys []string
-		n    *node
-		i    int
-	)
-	nodes := []*node{_node}
-	for l := len(nodes); l != 0; l = len(nodes) {
-		i = l - 1
-		n = nodes[i]
-		nodes = nodes[:i]
-		for _, c := range n.children {
-			nodes = append(nodes, c)
-		}
-		if n.term {
-			word := ""
-			for p := n.parent; p.depth != 0; p = p.parent {
-				word = string(p.val) + word
-			}
-			keys = append(keys, word)
-		}
+// Reports whether `partial` is a subsequence of the runes in `key`,
+// ignoring case.
+func fuzzymatch(key string, partial []rune) bool {
+	if len(partial) == 0 {
+		return true
 	}
-	return keys
-}
-
-type potentialSubtree struct {
-	idx  int
-	node *node
-}
-
-func fuzzycollect(node *node, partial []rune) []string {
-	var (
-		m    uint64
-		i    int
-		p    potentialSubtree
-		keys []string
-	)
-
-	potential := []potentialSubtree{potentialSubtree{node: node, idx: 0}}
-	for l := len(potential); l > 0; l = len(potential) {
-		i = l - 1
-		p = potential[i]
-		potential = potential[:i]
-		m = maskruneslice(partial[p.idx:])
-		if (p.node.mask & m) != m {
-			continue
-		}
-
-		if p.node.val == partial[p.idx] {
-			p.idx++
-			if p.idx == len(partial) {
-				keys = append(keys, collect(p.node)...)
-				continue
+	i := 0
+	for _, r := range key {
+		if unicode.ToLower(r) == unicode.ToLower(partial[i]) {
+			i++
+			if i == len(partial) {
+				return true
 			}
 		}
-
-		for _, c := range p.node.children {
-			potential = append(potential, potentialSubtree{node: c, idx: p.idx})
-		}
 	}
-	return keys
-}
\ No newline at end of file
+	return false
+}
@@ -1,8 +1,10 @@
 package zipfs
 
 import (
+	"fmt"
 	"sort"
 	"testing"
+	"unicode"
 )
 
 func TestTrieAdd(t *testing.T) {
@@ -302,4 +304,144 @@ func TestSupportChinese(t *testing.T) {
 			}
 		}
 	}
-}
\ No newline at end of file
+}
+
+func benchmarkPaths(n int) []string {
+	paths := make([]string, 0, n)
+	for i := 0; len(paths) < n; i++ {
+		paths = append(paths, fmt.Sprintf("/assets/dir%03d/sub%02d/file%06d.txt", i%997, i%31, i))
+	}
+	return paths
+}
+
+func BenchmarkTrieAdd(b *testing.B) {
+	for _, size := range []int{1000, 10000, 200000} {
+		paths := benchmarkPaths(size)
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				trie := newTrie()
+				for _, p := range paths {
+					trie.Add(p, nil)
+				}
+				trie.Find("/")
+			}
+		})
+	}
+}
+
+func BenchmarkTrieFind(b *testing.B) {
+	paths := benchmarkPaths(200000)
+	trie := newTrie()
+	for _, p := range paths {
+		trie.Add(p, nil)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		trie.Find(paths[i%len(paths)])
+	}
+}
+
+// Baseline for BenchmarkTrieFind.
+func BenchmarkMapFind(b *testing.B) {
+	paths := benchmarkPaths(200000)
+	m := make(map[string]interface{}, len(paths))
+	for _, p := range paths {
+		m[p] = nil
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = m[paths[i%len(paths)]]
+	}
+}
+
+func BenchmarkTriePrefixSearch(b *testing.B) {
+	trie := newTrie()
+	for _, p := range benchmarkPaths(200000) {
+		trie.Add(p, nil)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		trie.PrefixSearch(fmt.Sprintf("/assets/dir%03d/", i%997))
+	}
+}
+
+func TestMaskRune(t *testing.T) {
+	for _, r := range "abcxyzABCXYZ0189/._-~文档说明é\U0001F600" {
+		if m := maskrune(r); m == 0 || m&(m-1) != 0 {
+			t.Errorf("maskrune(%q) = %#x, expected a single bit", r, m)
+		}
+		if maskrune(r) != maskrune(unicode.ToLower(r)) {
+			t.Errorf("maskrune(%q) differs from its lower case", r)
+		}
+	}
+}
+
+func TestFuzzySearchFilePaths(t *testing.T) {
+	trie := newTrie()
+	setup := []string{
+		"/Assets/Img_01.PNG",
+		"/Assets/img_02.png",
+		"/Assets/Icons/~home.svg",
+		"/文档/说明.txt",
+		"/文档/README.md",
+		"/café/menü.html",
+	}
+	tests := []struct {
+		partial  string
+		expected []string
+	}{
+		{"Img01", []string{"/Assets/Img_01.PNG"}},
+		{"img01png", []string{"/Assets/Img_01.PNG"}},
+		{"ASSETSPNG", []string{"/Assets/Img_01.PNG", "/Assets/img_02.png"}},
+		{"/~", []string{"/Assets/Icons/~home.svg"}},
+		{"_0", []string{"/Assets/Img_01.PNG", "/Assets/img_02.png"}},
+		{"说明", []string{"/文档/说明.txt"}},
+		{"文档/", []string{"/文档/说明.txt", "/文档/README.md"}},
+		{"文md", []string{"/文档/README.md"}},
+		{"CAFÉMENÜ", []string{"/café/menü.html"}},
+		{"说明.md", nil},
+	}
+
+	for _, key := range setup {
+		trie.Add(key, nil)
+	}
+
+	for _, test := range tests {
+		actual := trie.FuzzySearch(test.partial)
+		sort.Strings(actual)
+		sort.Strings(test.expected)
+		if len(actual) != len(test.expected) {
+			t.Errorf("FuzzySearch(%q): expected %v got %v", test.partial, test.expected, actual)
+			continue
+		}
+		for i, key := range actual {
+			if key != test.expected[i] {
+				t.Errorf("FuzzySearch(%q): expected %v got %v", test.partial, test.expected, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestRemoveFilePaths(t *testing.T) {
+	trie := newTrie()
+	for _, key := range []string{"/Assets/Img_01.PNG", "/Assets/IMG_02.PNG", "/文档/说明.txt"} {
+		trie.Add(key, nil)
+	}
+
+	trie.Remove("/Assets/IMG_02.PNG")
+
+	if keys := trie.FuzzySearch("png"); len(keys) != 1 || keys[0] != "/Assets/Img_01.PNG" {
+		t.Errorf("Expected [/Assets/Img_01.PNG] got %v", keys)
+	}
+	if keys := trie.FuzzySearch("说明"); len(keys) != 1 {
+		t.Errorf("Expected 1 key got %v", keys)
+	}
+}
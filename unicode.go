@@ -0,0 +1,181 @@
+package zipfs
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Normalise the names of the entries and the paths looked up to Unicode Normalization
+// Form C, so names stored decomposed, as zip files created on macOS do, match paths
+// that are composed, as URLs usually are.
+func NormalizeNames() Option {
+	return func(fs *zipFS) {
+		fs.normalize = true
+	}
+}
+
+// Decode the names of entries that are not flagged as UTF-8 from code page 437, the
+// legacy encoding of zip files. Names that are valid UTF-8 are kept as they are, as
+// many archivers store UTF-8 without setting the flag.
+func DecodeLegacyNames() Option {
+	return func(fs *zipFS) {
+		fs.decodeLegacy = true
+	}
+}
+
+func decodeCP437(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x80 {
+			b.WriteByte(c)
+		} else {
+			b.WriteRune(cp437[c-0x80])
+		}
+	}
+	return b.String()
+}
+
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+//go:generate go run gen_nfc.go
+
+var (
+	nfcOnce      sync.Once
+	nfcDecompose map[rune][2]rune
+	nfcCompose   map[[2]rune]rune
+)
+
+func nfcInit() {
+	nfcDecompose = make(map[rune][2]rune, len(nfcDecompositions))
+	nfcCompose = make(map[[2]rune]rune, len(nfcDecompositions))
+	excluded := make(map[rune]bool, len(nfcExclusions))
+	for _, r := range nfcExclusions {
+		excluded[r] = true
+	}
+	for _, d := range nfcDecompositions {
+		nfcDecompose[d[0]] = [2]rune{d[1], d[2]}
+		if d[2] != 0 && !excluded[d[0]] {
+			nfcCompose[[2]rune{d[1], d[2]}] = d[0]
+		}
+	}
+}
+
+func combiningClass(r rune) uint8 {
+	if r < 0x300 {
+		return 0
+	}
+	i := sort.Search(len(nfcCombiningClasses), func(i int) bool { return nfcCombiningClasses[i].hi >= r })
+	if i < len(nfcCombiningClasses) && nfcCombiningClasses[i].lo <= r {
+		return nfcCombiningClasses[i].class
+	}
+	return 0
+}
+
+// Return s in Unicode Normalization Form C: canonically decomposed, with combining
+// marks in canonical order, then canonically composed.
+func nfc(s string) string {
+	quick := true
+	for _, r := range s {
+		if r >= 0x300 {
+			quick = false
+			break
+		}
+	}
+	if quick {
+		return s
+	}
+	nfcOnce.Do(nfcInit)
+
+	var runes []rune
+	for _, r := range s {
+		runes = decompose(runes, r)
+	}
+
+	// Sort each run of combining marks by class, keeping the order within a class.
+	for i := 0; i < len(runes); {
+		if combiningClass(runes[i]) == 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && combiningClass(runes[j]) != 0 {
+			j++
+		}
+		marks := runes[i:j]
+		sort.SliceStable(marks, func(a, b int) bool { return combiningClass(marks[a]) < combiningClass(marks[b]) })
+		i = j
+	}
+
+	return string(compose(runes))
+}
+
+func decompose(runes []rune, r rune) []rune {
+	if s := r - hangulSBase; s >= 0 && s < hangulSCount {
+		runes = append(runes, hangulLBase+s/hangulNCount, hangulVBase+s%hangulNCount/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			runes = append(runes, hangulTBase+t)
+		}
+		return runes
+	}
+	d, ok := nfcDecompose[r]
+	if !ok {
+		return append(runes, r)
+	}
+	runes = decompose(runes, d[0])
+	if d[1] != 0 {
+		runes = decompose(runes, d[1])
+	}
+	return runes
+}
+
+func composePair(a, b rune) (rune, bool) {
+	if l, v := a-hangulLBase, b-hangulVBase; l >= 0 && l < hangulLCount && v >= 0 && v < hangulVCount {
+		return hangulSBase + (l*hangulVCount+v)*hangulTCount, true
+	}
+	if s, t := a-hangulSBase, b-hangulTBase; s >= 0 && s < hangulSCount && s%hangulTCount == 0 && t > 0 && t < hangulTCount {
+		return a + t, true
+	}
+	c, ok := nfcCompose[[2]rune{a, b}]
+	return c, ok
+}
+
+// Canonically compose the decomposed and ordered runes in place. A mark combines with
+// the last starter unless a mark of the same or a higher class lies between them.
+func compose(runes []rune) []rune {
+	if len(runes) == 0 {
+		return runes
+	}
+	out := runes[:1]
+	starter := 0
+	last := int(combiningClass(runes[0]))
+	if last != 0 {
+		// No starter to combine with.
+		last = 256
+	}
+	for _, r := range runes[1:] {
+		class := int(combiningClass(r))
+		if last < class || last == 0 {
+			if c, ok := composePair(out[starter], r); ok {
+				out[starter] = c
+				continue
+			}
+		}
+		if class == 0 {
+			starter = len(out)
+		}
+		last = class
+		out = append(out, r)
+	}
+	return out
+}
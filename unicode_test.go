@@ -0,0 +1,65 @@
+package zipfs
+
+import (
+	"testing"
+)
+
+func TestNFC(t *testing.T) {
+	tests := []struct {
+		in, expected string
+	}{
+		{"plain/ascii.txt", "plain/ascii.txt"},
+		{"caf\u00e9", "caf\u00e9"},
+		{"cafe\u0301", "caf\u00e9"},
+		{"A\u030a", "\u00c5"},
+		{"\u212b", "\u00c5"},
+		{"D\u0307\u0323", "\u1e0c\u0307"},
+		{"\u1e0b\u0323", "\u1e0d\u0307"},
+		{"q\u0307\u0323", "q\u0323\u0307"},
+		{"\u1100\u1161\u11a8", "\uac01"},
+		{"\u1112\u1161\u11ab\u1100\u1173\u11af", "\ud55c\uae00"},
+		{"\u0958", "\u0915\u093c"},
+		{"e\u0301\u0301", "\u00e9\u0301"},
+		{"\u0301e", "\u0301e"},
+		{"/\u6587\u6863/\u8bf4\u660e.txt", "/\u6587\u6863/\u8bf4\u660e.txt"},
+	}
+	for _, test := range tests {
+		if actual := nfc(test.in); actual != test.expected {
+			t.Errorf("nfc(%+q): expected %+q got %+q", test.in, test.expected, actual)
+		}
+	}
+}
+
+func TestZipFS_NormalizeNames(t *testing.T) {
+	fs := NewZipFS(zipOf("cafe\u0301/menu\u0308.html", "nai\u0308ve.txt"), NormalizeNames())
+
+	for _, name := range []string{"/caf\u00e9/men\u00fc.html", "/cafe\u0301/menu\u0308.html", "/nai\u0308ve.txt", "/na\u00efve.txt"} {
+		if _, err := fs.Open(name); err != nil {
+			t.Errorf("Open(%+q): %v", name, err)
+		}
+	}
+
+	dir, _ := fs.Open("/caf\u00e9")
+	infos, _ := dir.Readdir(-1)
+	if len(infos) != 1 || infos[0].Name() != "men\u00fc.html" {
+		t.Errorf("Expected the listing to hold the normalised name, got %v", infos)
+	}
+
+	if _, err := NewZipFS(zipOf("cafe\u0301.txt")).Open("/caf\u00e9.txt"); err == nil {
+		t.Error("Expected names not to be normalised by default")
+	}
+}
+
+func TestZipFS_DecodeLegacyNames(t *testing.T) {
+	z := zipOf("caf\x82/\x9curo.txt", "plain.txt", "men\u00fc.txt")
+	fs := NewZipFS(z, DecodeLegacyNames())
+
+	for _, name := range []string{"/caf\u00e9/\u00a3uro.txt", "/plain.txt", "/men\u00fc.txt"} {
+		if _, err := fs.Open(name); err != nil {
+			t.Errorf("Open(%+q): %v", name, err)
+		}
+	}
+	if _, err := NewZipFS(z).Open("/caf\u00e9/\u00a3uro.txt"); err == nil {
+		t.Error("Expected names not to be decoded by default")
+	}
+}
@@ -0,0 +1,104 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// Check the CRC32 of stored files read from the readerAt, once read sequentially from
+// the start to the end. Compressed files are always checked.
+func VerifyCRC() Option {
+	return func(fs *zipFS) {
+		fs.verifyCRC = true
+	}
+}
+
+// Verifier is implemented by file systems that can check the content of every file.
+type Verifier interface {
+	// Verify reads every file to the end, returning a *VerifyError listing the corrupt
+	// ones, if any.
+	Verify() error
+}
+
+// Error reading a file in the archive.
+type EntryError struct {
+	Name string
+	Err  error
+}
+
+func (e *EntryError) Error() string { return "zipfs: " + e.Name + ": " + e.Err.Error() }
+func (e *EntryError) Unwrap() error { return e.Err }
+
+// Error returned by Verify, listing the corrupt files in path order.
+type VerifyError struct {
+	Entries []*EntryError
+}
+
+func (e *VerifyError) Error() string {
+	if len(e.Entries) == 1 {
+		return e.Entries[0].Error()
+	}
+	return fmt.Sprintf("zipfs: %d corrupt files, first %v", len(e.Entries), e.Entries[0])
+}
+
+func (fs *zipFS) Verify() error {
+	if err := fs.ready(); err != nil {
+		return err
+	}
+	verr := &VerifyError{}
+	for _, n := range fs.trie.prefixNodes("/") {
+		entry, ok := n.meta.(*zipEntry)
+		if !ok {
+			continue
+		}
+		if err := fs.verifyEntry(entry); err != nil {
+			verr.Entries = append(verr.Entries, &EntryError{Name: n.key, Err: err})
+		}
+	}
+	if len(verr.Entries) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func (fs *zipFS) verifyEntry(entry *zipEntry) error {
+	rc, err := fs.openEntry(entry)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(io.Discard, &limitedReader{ReadCloser: rc, remaining: entry.UncompressedSize64})
+	return err
+}
+
+// Computes the CRC32 of the bytes read in sequence from the start of a stored file,
+// failing the read that reaches the end when it does not match.
+type crcVerifier struct {
+	hash  hash.Hash32
+	nread int64
+}
+
+func newCRCVerifier() *crcVerifier {
+	return &crcVerifier{hash: crc32.NewIEEE()}
+}
+
+// Account for the n bytes of b read at offset off of the file.
+func (v *crcVerifier) read(entry *zipEntry, off int64, b []byte) error {
+	if off == 0 {
+		// Reading again from the start, as http.ServeContent does after sniffing.
+		v.hash.Reset()
+		v.nread = 0
+	}
+	if off != v.nread || len(b) == 0 {
+		return nil
+	}
+	v.hash.Write(b)
+	v.nread += int64(len(b))
+	if uint64(v.nread) == entry.UncompressedSize64 && entry.CRC32 != 0 && v.hash.Sum32() != entry.CRC32 {
+		return zip.ErrChecksum
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Creates an archive of two stored files, bad.txt corrupted after its CRC32 was written.
+func corruptZip() *bytes.Reader {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, h := range []*zip.FileHeader{
+		{Name: "bad.txt", Method: zip.Store},
+		{Name: "good.txt", Method: zip.Store},
+	} {
+		f, _ := w.CreateHeader(h)
+		io.WriteString(f, "content of "+h.Name)
+	}
+	w.Close()
+	b := buf.Bytes()
+	i := bytes.Index(b, []byte("content of bad.txt"))
+	b[i] = 'C'
+	return bytes.NewReader(b)
+}
+
+func TestZipFS_VerifyCRC(t *testing.T) {
+	r := corruptZip()
+	z, _ := zip.NewReader(r, r.Size())
+
+	fs := NewZipFSWithReaderAt(z, r)
+	if content := readAll(t, fs, "/bad.txt"); string(content) != "Content of bad.txt" {
+		t.Errorf("Expected the content to be served unchecked got %q", content)
+	}
+
+	fs = NewZipFSWithReaderAt(z, r, VerifyCRC())
+	file, _ := fs.Open("/bad.txt")
+	if _, err := io.ReadAll(file); err != zip.ErrChecksum {
+		t.Errorf("Expected %v got %v", zip.ErrChecksum, err)
+	}
+	// Partial reads are not checked, reading from the start again is.
+	file.Seek(4, io.SeekStart)
+	if _, err := io.ReadAll(file); err != nil {
+		t.Errorf("Expected partial read to succeed got %v", err)
+	}
+	file.Seek(0, io.SeekStart)
+	if _, err := io.ReadAll(file); err != zip.ErrChecksum {
+		t.Errorf("Expected %v after seeking to the start got %v", zip.ErrChecksum, err)
+	}
+	if content := readAll(t, fs, "/good.txt"); string(content) != "content of good.txt" {
+		t.Errorf("Expected good content got %q", content)
+	}
+
+	// http.ServeContent sniffs the start of the file before serving all of it.
+	rec := httptest.NewRecorder()
+	http.FileServer(fs).ServeHTTP(rec, httptest.NewRequest("GET", "/good.txt", nil))
+	if rec.Body.String() != "content of good.txt" {
+		t.Errorf("Expected good content served got %q", rec.Body.String())
+	}
+}
+
+func TestZipFS_Verify(t *testing.T) {
+	r := corruptZip()
+	z, _ := zip.NewReader(r, r.Size())
+
+	err := NewZipFSWithReaderAt(z, r).(Verifier).Verify()
+	var verr *VerifyError
+	if !errors.As(err, &verr) || len(verr.Entries) != 1 || verr.Entries[0].Name != "/bad.txt" {
+		t.Fatalf("Expected /bad.txt to be corrupt got %v", err)
+	}
+	if !errors.Is(verr.Entries[0], zip.ErrChecksum) {
+		t.Errorf("Expected %v got %v", zip.ErrChecksum, verr.Entries[0].Err)
+	}
+
+	if err := NewZipFSWithReaderAt(duplicatesZip(), nil).(Verifier).Verify(); err != nil {
+		t.Errorf("Expected no corrupt files got %v", err)
+	}
+}
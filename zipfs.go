@@ -32,116 +32,249 @@ package zipfs
 
 import (
 	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
 	"errors"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"strings"
+	"sync"
 	"time"
 )
 
+// Option configures a Zip File System.
+type Option func(fs *zipFS)
+
 // Create Zip File System, just from the zip reader, with seek disabled.
-func NewZipFS(z *zip.Reader) http.FileSystem { return NewZipFSWithReaderAt(z, nil) }
+func NewZipFS(z *zip.Reader, opts ...Option) http.FileSystem {
+	return NewZipFSWithReaderAt(z, nil, opts...)
+}
 
 // Create Zip File System, from the zip reader and readerAt.
 // If readerAt is nil, than seeking will be disabled.
-func NewZipFSWithReaderAt(z *zip.Reader, readerAt io.ReaderAt) http.FileSystem {
-	trie := newTrie()
-	rootDir := &zipRoot{
-		zipDir: zipDir{},
-		Info:   zipRootInfo{time.Now()},
-	}
-	dirs := []*zip.File{}
-	for _, entry := range z.File {
-		if entry.Mode().IsDir() {
-			dirs = append(dirs, entry)
-			trie.Add("/"+strings.TrimRight(entry.Name, "/"), entry)
-		} else {
-			trie.Add("/"+entry.Name, entry)
-		}
-		if len(strings.Split(strings.TrimRight(entry.Name, "/"), "/")) == 1 {
-			clone := *entry
-			rootDir.Files = append(rootDir.Files, &clone)
-		}
-	}
-	for _, entry := range dirs {
-		// fake directory.
-		dir := &zipDir{Info: entry.FileHeader}
-		name := "/" + strings.TrimRight(entry.Name, "/")
-		for _, dirContent := range trie.PrefixSearch(name) {
-			if !strings.HasPrefix(dirContent, "/"+entry.Name) ||
-				len(strings.Split(strings.TrimRight(strings.TrimPrefix(dirContent, "/"+entry.Name), "/"), "/")) > 1 {
-				continue
-			}
-			node, _ := trie.Find(dirContent)
-			subentry := node.meta.(*zip.File)
-			clone := *subentry
-			clone.Name = subentry.Name[len(entry.Name):]
-			dir.Files = append(dir.Files, &clone)
-		}
+// An error indexing the archive is returned by Open, use OpenZipFS to get it straight away.
+func NewZipFSWithReaderAt(z *zip.Reader, readerAt io.ReaderAt, opts ...Option) http.FileSystem {
+	fs := newZipFS(readerAt, opts)
+	fs.open(fs.zipReaderEntries(z))
+	return fs
+}
 
-		trie.Add(name, *dir)
+// Create Zip File System, from the zip reader and readerAt, returning any error indexing
+// the archive. If readerAt is nil, than seeking will be disabled.
+func OpenZipFS(z *zip.Reader, readerAt io.ReaderAt, opts ...Option) (http.FileSystem, error) {
+	fs := newZipFS(readerAt, opts)
+	if err := fs.open(fs.zipReaderEntries(z)); err != nil {
+		return nil, err
 	}
-	trie.Add("/", *rootDir)
+	return fs, nil
+}
 
-	return &zipFS{
-		zip:      z,
-		readerAt: readerAt,
-		trie:     trie,
+// Load the entries of z, after checking the signature of the archive in the readerAt.
+func (fs *zipFS) zipReaderEntries(z *zip.Reader) func() ([]*zipEntry, error) {
+	return func() ([]*zipEntry, error) {
+		if err := fs.verifySignature(fs.readerAt, readerSize(fs.readerAt)); err != nil {
+			return nil, err
+		}
+		return newZipEntries(z.File), nil
 	}
 }
 
 type zipFS struct {
-	zip      *zip.Reader
+	// Updated atomically, so first for their alignment on 32 bit platforms.
+	cacheHits   uint64
+	cacheMisses uint64
+
 	readerAt io.ReaderAt
 	trie     *trie
+
+	load func() ([]*zipEntry, error)
+	once sync.Once
+	err  error
+
+	lazy bool
+	dirs *lru
+
+	folded map[string]string
+	report Report
+
+	normalize    bool
+	decodeLegacy bool
+	namePolicy   NamePolicy
+
+	duplicatePolicy DuplicatePolicy
+	limits          Limits
+	verifyCRC       bool
+	publicKey       ed25519.PublicKey
+	password        func(name string) (string, error)
+	decompressors   map[uint16]zip.Decompressor
+
+	spillMemory int64
+	spillSize   int64
+	archives    *lru
+
+	seekSpan    int64
+	seekIndexes *lru
+
+	cache         *lru
+	cacheFileSize int64
+
+	// Content types by extension and by path.
+	types     map[string]string
+	pathTypes map[string]string
+	// Response headers by path.
+	headers *trie
+}
+
+func newZipFS(readerAt io.ReaderAt, opts []Option) *zipFS {
+	fs := &zipFS{
+		readerAt:      readerAt,
+		trie:          newTrie(),
+		decompressors: defaultDecompressors(),
+		spillMemory:   defaultSpillMemory,
+	}
+	for _, opt := range opts {
+		opt(fs)
+	}
+	return fs
+}
+
+// Set the function that reads the entries of the zip archive and index them, straight
+// away unless the file system is lazy.
+func (fs *zipFS) open(load func() ([]*zipEntry, error)) error {
+	fs.load = load
+	if fs.lazy {
+		return nil
+	}
+	return fs.ready()
+}
+
+// Read and index the zip archive if that has not been done yet.
+func (fs *zipFS) ready() error {
+	fs.once.Do(func() {
+		if fs.load == nil {
+			return
+		}
+		entries, err := fs.load()
+		if err == nil {
+			err = fs.build(entries)
+		}
+		fs.err = err
+	})
+	return fs.err
 }
 
 func (fs *zipFS) Open(name string) (http.File, error) {
 	if !strings.HasPrefix(name, "/") {
 		return nil, os.ErrNotExist
 	}
-	node, found := fs.trie.Find(name)
+	if err := fs.ready(); err != nil {
+		return nil, err
+	}
+	dirSlash := strings.HasSuffix(name, "/")
+	name = path.Clean(name)
+	canonical, found := fs.canonical(name)
 	if !found {
+		if fs.archives != nil {
+			if f, found, err := fs.openNested(name); found {
+				return f, err
+			}
+		}
 		return nil, os.ErrNotExist
 	}
+	name = canonical
+	node, _ := fs.trie.Find(name)
 
 	switch entry := node.meta.(type) {
-	case *zip.File:
+	case *zipEntry:
+		// Only /docs/v1.zip/ opens the root of the zip file, /docs/v1.zip is the file itself.
+		if fs.archives != nil && dirSlash && strings.HasSuffix(strings.ToLower(name), ".zip") {
+			if f, _, err := fs.openNested(name); err == nil {
+				return f, nil
+			}
+		}
 		return fs.processZipFile(entry)
-	case zipDir:
-		return &entry, nil
-	case zipRoot:
-		return &entry, nil
+	case *zipDir:
+		dir := *entry
+		if fs.lazy {
+			dir.Files = fs.listing(name)
+		}
+		return &dir, nil
+	case *zipRoot:
+		root := *entry
+		if fs.lazy {
+			root.Files = fs.listing(name)
+		}
+		return &root, nil
 	}
 
 	return nil, os.ErrNotExist
 }
 
-func (fs *zipFS) processZipFile(entry *zip.File) (http.File, error) {
+func (fs *zipFS) processZipFile(entry *zipEntry) (http.File, error) {
 	if fs.readerAt != nil && entry.Method == zip.Store {
-		offset, err := entry.DataOffset()
+		offset, err := entry.dataOffset()
 		if err != nil {
 			return nil, err
 		}
-		return &uncompressedFile{
+		file := &uncompressedFile{
 			SectionReader: io.NewSectionReader(fs.readerAt, offset, int64(entry.UncompressedSize64)),
 			zipFile:       entry,
+		}
+		if fs.verifyCRC {
+			file.crc = newCRCVerifier()
+		}
+		return file, nil
+	}
+	if fs.cache != nil && entry.UncompressedSize64 <= uint64(fs.cacheFileSize) {
+		b, err := fs.cached(entry)
+		if err != nil {
+			return nil, err
+		}
+		return &cachedFile{Reader: bytes.NewReader(b), zipFile: entry}, nil
+	}
+	if fs.seekIndexes != nil && fs.readerAt != nil && entry.Method == zip.Deflate {
+		offset, err := entry.dataOffset()
+		if err != nil {
+			return nil, err
+		}
+		return &compressedFile{
+			ReadCloser: &seekableReader{
+				fs:    fs,
+				entry: entry,
+				raw:   io.NewSectionReader(fs.readerAt, offset, int64(entry.CompressedSize64)),
+				crc:   newCRCVerifier(),
+			},
+			zipFile: entry,
 		}, nil
 	}
-	ff, err := entry.Open()
+	ff, err := fs.openEntry(entry)
 	if err != nil {
 		return nil, err
 	}
 	return &compressedFile{
-		ReadCloser: ff,
+		ReadCloser: &limitedReader{ReadCloser: ff, remaining: entry.UncompressedSize64},
 		zipFile:    entry,
 	}, nil
 }
 
 type uncompressedFile struct {
 	*io.SectionReader
-	zipFile *zip.File
+	zipFile *zipEntry
+	crc     *crcVerifier
+}
+
+func (f *uncompressedFile) Read(b []byte) (int, error) {
+	if f.crc == nil {
+		return f.SectionReader.Read(b)
+	}
+	off, _ := f.SectionReader.Seek(0, io.SeekCurrent)
+	n, err := f.SectionReader.Read(b)
+	if cerr := f.crc.read(f.zipFile, off, b[:n]); cerr != nil {
+		err = cerr
+	}
+	return n, err
 }
 
 func (f *uncompressedFile) Close() error               { return nil }
@@ -153,10 +286,13 @@ func (f *uncompressedFile) Readdir(count int) ([]os.FileInfo, error) {
 
 type compressedFile struct {
 	io.ReadCloser
-	zipFile *zip.File
+	zipFile *zipEntry
 }
 
 func (f *compressedFile) Seek(offset int64, whence int) (int64, error) {
+	if s, ok := f.ReadCloser.(io.Seeker); ok {
+		return s.Seek(offset, whence)
+	}
 	return -1, errors.New("seek on compressed file")
 }
 
@@ -169,8 +305,8 @@ func (f *compressedFile) Stat() (os.FileInfo, error) {
 }
 
 type zipDir struct {
-	Info  zip.FileHeader
-	Files []*zip.File
+	Info  *zip.FileHeader
+	Files []*zip.FileHeader
 }
 
 func (f *zipDir) Close() error                              { return nil }
@@ -1,6 +1,9 @@
 package zipfs
 
 import (
+	"archive/zip"
+	"bytes"
+	"fmt"
 	"testing"
 )
 
@@ -246,6 +249,45 @@ func TestZipFS_Open(t *testing.T) {
 	})
 }
 
+func TestZipFS_ImplicitDirectories(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, name := range []string{"a/b/c.txt", "a/d.txt", "e.txt"} {
+		w.Create(name)
+	}
+	w.Close()
+	z, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	fs := NewZipFS(z)
+
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{"/", 2},
+		{"/a", 2},
+		{"/a/b", 1},
+	}
+	for _, test := range tests {
+		file, err := fs.Open(test.name)
+		if err != nil {
+			t.Fatalf("Open(%q): %v", test.name, err)
+		}
+		fi, _ := file.Stat()
+		if !fi.IsDir() {
+			t.Errorf("Open(%q): expected a directory", test.name)
+		}
+		if infos, _ := file.Readdir(-1); len(infos) != test.count {
+			t.Errorf("Open(%q): expected %d entries got %d", test.name, test.count, len(infos))
+		}
+	}
+
+	file, _ := fs.Open("/a")
+	infos, _ := file.Readdir(-1)
+	if infos[0].Name() != "b" || !infos[0].IsDir() || infos[1].Name() != "d.txt" {
+		t.Errorf("Unexpected listing %v, %v", infos[0].Name(), infos[1].Name())
+	}
+}
+
 func BenchmarkZipFS_Open(b *testing.B) {
 	b.Run("Without Compression", func(b *testing.B) {
 		fs := InitZipFs("testdata/uncompressed.zip")
@@ -265,3 +307,58 @@ func BenchmarkZipFS_Open(b *testing.B) {
 		}
 	})
 }
+
+// Creates an in memory archive with n empty files spread over nested directories.
+func syntheticZip(n int) *zip.Reader {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for i := 0; i < n; i++ {
+		if i%100 == 0 {
+			w.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("dir%03d/sub%02d/", i%997, i%31)})
+		}
+		w.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("dir%03d/sub%02d/file%06d.txt", i%997, i%31, i)})
+	}
+	w.Close()
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		panic(err)
+	}
+	return z
+}
+
+func BenchmarkNewZipFSWithReaderAt(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000} {
+		z := syntheticZip(size)
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				fs := NewZipFSWithReaderAt(z, nil)
+				fs.Open("/")
+			}
+		})
+	}
+}
+
+// Opening files concurrently only reads the index, run with -race.
+func TestZipFS_ConcurrentOpen(t *testing.T) {
+	fs := NewZipFS(syntheticZip(100))
+	done := make(chan bool)
+	for i := 0; i < 8; i++ {
+		go func(i int) {
+			for j := 0; j < 100; j++ {
+				n := (i + j) % 100
+				f, err := fs.Open(fmt.Sprintf("/dir%03d/sub%02d/file%06d.txt", n%997, n%31, n))
+				if err != nil {
+					t.Error(err)
+					break
+				}
+				f.Close()
+			}
+			done <- true
+		}(i)
+	}
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+}